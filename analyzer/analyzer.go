@@ -0,0 +1,270 @@
+// Package analyzer provides a go/analysis analyzer that finds Go code using
+// deprecated protobuf APIs: setting deprecated fields of generated messages,
+// referencing deprecated enum values (e.g. in switch statements), and calling
+// deprecated RPC methods of generated gRPC and connect-go clients.
+//
+// Deprecated elements and their DeprecationDetails are read from registered
+// descriptors, which are mapped to Go identifiers by the go_package option and
+// the naming rules of protoc-gen-go. Diagnostics are categorized by the
+// effective date of the deprecation: CategoryPastDue or CategoryDeprecated.
+// Generated files are not reported.
+//
+// Analyzer uses protoregistry.GlobalFiles, so the descriptors of the APIs must be
+// linked into the checker binary, e.g. with blank imports of the generated
+// packages in a singlechecker main package. Alternatively, use New with the
+// descriptors loaded by apideprecation.LoadDescriptorSets, as
+// cmd/apideprecation-lint does.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/go/analysis"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	apideprecation "github.com/belo4ya/grpc-api-deprecation"
+)
+
+// Diagnostic categories.
+const (
+	// CategoryPastDue is the category of uses of elements whose effective_at
+	// has passed.
+	CategoryPastDue = "past-due"
+	// CategoryDeprecated is the category of uses of elements whose effective_at
+	// is in the future or not set.
+	CategoryDeprecated = "deprecated"
+)
+
+// Analyzer reports uses of the deprecated elements of protoregistry.GlobalFiles.
+var Analyzer = New(Config{})
+
+// Config configures an analyzer created by New.
+type Config struct {
+	// Files provides the descriptors of the APIs. Defaults to protoregistry.GlobalFiles.
+	Files *protoregistry.Files
+	// Now is the time the effective dates are compared with. Defaults to time.Now.
+	Now func() time.Time
+	// PastDueOnly reports only uses of elements whose effective_at has passed.
+	PastDueOnly bool
+}
+
+// New creates an analyzer that reports uses of the deprecated elements of
+// cfg.Files. The Go identifiers of the elements are resolved on first use.
+func New(cfg Config) *analysis.Analyzer {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	idx := sync.OnceValue(func() *index {
+		files := cfg.Files
+		if files == nil {
+			files = protoregistry.GlobalFiles
+		}
+		return newIndex(files)
+	})
+	return &analysis.Analyzer{
+		Name: "apideprecation",
+		Doc:  "report uses of deprecated protobuf fields, enum values, and RPC methods",
+		URL:  "https://pkg.go.dev/github.com/belo4ya/grpc-api-deprecation/analyzer",
+		Run: func(pass *analysis.Pass) (any, error) {
+			(&checker{pass: pass, idx: idx(), now: cfg.Now(), pastDueOnly: cfg.PastDueOnly}).run()
+			return nil, nil
+		},
+	}
+}
+
+type checker struct {
+	pass        *analysis.Pass
+	idx         *index
+	now         time.Time
+	pastDueOnly bool
+}
+
+func (c *checker) run() {
+	for _, file := range c.pass.Files {
+		if ast.IsGenerated(file) {
+			continue
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				for _, lhs := range n.Lhs {
+					c.checkFieldSet(lhs)
+				}
+			case *ast.IncDecStmt:
+				c.checkFieldSet(n.X)
+			case *ast.CompositeLit:
+				c.checkCompositeLit(n)
+			case *ast.CallExpr:
+				c.checkCall(n)
+			case *ast.Ident:
+				c.checkEnumValue(n)
+			}
+			return true
+		})
+	}
+}
+
+// checkFieldSet checks the left-hand side of an assignment, e.g. `req.Title = "t"`.
+func (c *checker) checkFieldSet(lhs ast.Expr) {
+	sel, ok := ast.Unparen(lhs).(*ast.SelectorExpr)
+	if !ok {
+		return
+	}
+	selection, ok := c.pass.TypesInfo.Selections[sel]
+	if !ok || selection.Kind() != types.FieldVal {
+		return
+	}
+	c.checkField(sel.Sel, selection.Recv(), selection.Obj())
+}
+
+// checkCompositeLit checks the keys of a message literal, e.g. `&pb.Request{Title: "t"}`.
+func (c *checker) checkCompositeLit(lit *ast.CompositeLit) {
+	typ := c.pass.TypesInfo.TypeOf(lit)
+	if typ == nil {
+		return
+	}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		if key, ok := kv.Key.(*ast.Ident); ok {
+			c.checkField(key, typ, c.pass.TypesInfo.ObjectOf(key))
+		}
+	}
+}
+
+func (c *checker) checkField(node ast.Node, recv types.Type, obj types.Object) {
+	field, ok := obj.(*types.Var)
+	if !ok || !field.IsField() {
+		return
+	}
+	named := namedType(recv)
+	if named == nil || named.Obj().Pkg() == nil {
+		return
+	}
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return
+	}
+	for i := range st.NumFields() {
+		if st.Field(i) != field {
+			continue
+		}
+		num, ok := fieldNumber(st.Tag(i))
+		if !ok {
+			return
+		}
+		key := goKey{pkg: named.Obj().Pkg().Path(), typ: named.Obj().Name(), num: num}
+		if e, ok := c.idx.fields[key]; ok {
+			c.report(node, "field", e)
+		}
+		return
+	}
+}
+
+// checkCall checks calls of client methods, e.g. `client.GetResourceLegacy(ctx, req)`.
+func (c *checker) checkCall(call *ast.CallExpr) {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return
+	}
+	selection, ok := c.pass.TypesInfo.Selections[sel]
+	if !ok || selection.Kind() != types.MethodVal {
+		return
+	}
+	named := namedType(selection.Recv())
+	if named == nil || named.Obj().Pkg() == nil {
+		return
+	}
+	key := goKey{pkg: named.Obj().Pkg().Path(), typ: named.Obj().Name(), name: sel.Sel.Name}
+	if e, ok := c.idx.methods[key]; ok {
+		c.report(sel.Sel, "method", e)
+	}
+}
+
+// checkEnumValue checks references of enum constants, e.g. `pb.State_STATE_LEGACY`.
+func (c *checker) checkEnumValue(id *ast.Ident) {
+	cnst, ok := c.pass.TypesInfo.Uses[id].(*types.Const)
+	if !ok {
+		return
+	}
+	named := namedType(cnst.Type())
+	if named == nil || named.Obj().Pkg() == nil {
+		return
+	}
+	key := goKey{pkg: named.Obj().Pkg().Path(), typ: named.Obj().Name(), name: cnst.Name()}
+	if e, ok := c.idx.enumValues[key]; ok {
+		c.report(id, "enum value", e)
+	}
+}
+
+func (c *checker) report(node ast.Node, kind string, e apideprecation.ElementDetails) {
+	effectiveAt, hasDate := parseDate(e.EffectiveAt)
+	pastDue := hasDate && !c.now.Before(effectiveAt)
+	if c.pastDueOnly && !pastDue {
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s is deprecated", kind, e.Name)
+	category := CategoryDeprecated
+	switch {
+	case pastDue:
+		category = CategoryPastDue
+		fmt.Fprintf(&sb, " and stopped working on %s", e.EffectiveAt)
+	case hasDate:
+		fmt.Fprintf(&sb, " and will stop working on %s", e.EffectiveAt)
+	}
+	if e.Description != "" {
+		sb.WriteString(": ")
+		sb.WriteString(e.Description)
+	}
+	if e.Replacement != "" {
+		fmt.Fprintf(&sb, " (replacement: %s)", e.Replacement)
+	}
+	c.pass.Report(analysis.Diagnostic{
+		Pos:      node.Pos(),
+		End:      node.End(),
+		Category: category,
+		Message:  sb.String(),
+		URL:      e.DocsURL,
+	})
+}
+
+func namedType(typ types.Type) *types.Named {
+	if ptr, ok := types.Unalias(typ).(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	named, _ := types.Unalias(typ).(*types.Named)
+	return named
+}
+
+// fieldNumber returns the field number of a `protobuf:"bytes,101,opt,name=title,proto3"` struct tag.
+func fieldNumber(tag string) (int32, bool) {
+	parts := strings.Split(reflect.StructTag(tag).Get("protobuf"), ",")
+	if len(parts) < 2 {
+		return 0, false
+	}
+	num, err := strconv.ParseInt(parts[1], 10, 32)
+	return int32(num), err == nil
+}
+
+// parseDate parses an effective_at date, e.g. "2025-06-01", or an RFC 3339 time.
+func parseDate(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
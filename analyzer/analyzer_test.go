@@ -0,0 +1,49 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/analysis/analysistest"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+// testFiles returns a registry with the testdata service, generated in the
+// example.com/api package of the analysistest stubs.
+func testFiles(t *testing.T) *protoregistry.Files {
+	fdp := protodesc.ToFileDescriptorProto(pb.File_service_proto)
+	fdp.Options.GoPackage = proto.String("example.com/api;pb")
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	files := &protoregistry.Files{}
+	require.NoError(t, files.RegisterFile(fd))
+	return files
+}
+
+func TestAnalyzer(t *testing.T) {
+	now := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	a := New(Config{Files: testFiles(t), Now: func() time.Time { return now }})
+	analysistest.Run(t, analysistest.TestData(), a, "client")
+
+	a = New(Config{Files: testFiles(t), Now: func() time.Time { return now }, PastDueOnly: true})
+	analysistest.Run(t, analysistest.TestData(), a, "pastdue")
+}
+
+func TestGoCamelCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"ResourceService": "ResourceService",
+		"Outer.Inner":     "Outer_Inner",
+		"Outer.inner":     "OuterInner",
+		"display_name":    "DisplayName",
+		"_foo":            "XFoo",
+		"foo2bar":         "Foo2Bar",
+	} {
+		assert.Equal(t, want, goCamelCase(in), in)
+	}
+}
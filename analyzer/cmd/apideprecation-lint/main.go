@@ -0,0 +1,89 @@
+// Command apideprecation-lint reports Go code using deprecated protobuf
+// fields, enum values, and methods.
+//
+// Usage:
+//
+//	apideprecation-lint -descriptors set.binpb [flags] packages...
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+
+	apideprecation "github.com/belo4ya/grpc-api-deprecation"
+	"github.com/belo4ya/grpc-api-deprecation/analyzer"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "apideprecation-lint: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("apideprecation-lint", flag.ContinueOnError)
+	descriptors := fs.String("descriptors", "", "binary FileDescriptorSet with the API descriptors (required)")
+	pastDueOnly := fs.Bool("past-due-only", false, "report only uses of elements whose effective_at has passed")
+	date := fs.String("date", "", "compare effective dates with this date (YYYY-MM-DD) instead of today")
+	tests := fs.Bool("test", false, "also check test files")
+	jsonOut := fs.Bool("json", false, "print diagnostics as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: apideprecation-lint -descriptors set.binpb [flags] packages...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *descriptors == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("-descriptors and at least one package are required")
+	}
+
+	files, err := apideprecation.LoadDescriptorSets(*descriptors)
+	if err != nil {
+		return err
+	}
+	cfg := analyzer.Config{Files: files, PastDueOnly: *pastDueOnly}
+	if *date != "" {
+		now, err := time.Parse(time.DateOnly, *date)
+		if err != nil {
+			return fmt.Errorf("-date: %w", err)
+		}
+		cfg.Now = func() time.Time { return now }
+	}
+
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.LoadAllSyntax, Tests: *tests}, fs.Args()...)
+	if err != nil {
+		return err
+	}
+	if n := packages.PrintErrors(pkgs); n > 0 {
+		return fmt.Errorf("%d errors loading packages", n)
+	}
+	graph, err := checker.Analyze([]*analysis.Analyzer{analyzer.New(cfg)}, pkgs, nil)
+	if err != nil {
+		return err
+	}
+	if *jsonOut {
+		return graph.PrintJSON(os.Stdout)
+	}
+
+	var found bool
+	for act := range graph.All() {
+		found = found || act.IsRoot && len(act.Diagnostics) != 0
+	}
+	if err := graph.PrintText(os.Stdout, -1); err != nil {
+		return err
+	}
+	if found {
+		return errors.New("deprecated API used")
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package analyzer
+
+import (
+	"path"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	apideprecation "github.com/belo4ya/grpc-api-deprecation"
+)
+
+// goKey identifies a generated Go element by its package path, type name, and
+// field number or method or enum constant name.
+type goKey struct {
+	pkg, typ string
+	num      int32
+	name     string
+}
+
+// index maps the Go identifiers generated for deprecated elements to their details.
+type index struct {
+	fields     map[goKey]apideprecation.ElementDetails // message or oneof wrapper type, field number
+	enumValues map[goKey]apideprecation.ElementDetails // enum type, constant name
+	methods    map[goKey]apideprecation.ElementDetails // client type, method name
+}
+
+func newIndex(files *protoregistry.Files) *index {
+	deprecated := map[protoreflect.FullName]apideprecation.ElementDetails{}
+	for _, e := range apideprecation.InventoryDetails(files) {
+		deprecated[e.Name] = e
+	}
+	idx := &index{
+		fields:     map[goKey]apideprecation.ElementDetails{},
+		enumValues: map[goKey]apideprecation.ElementDetails{},
+		methods:    map[goKey]apideprecation.ElementDetails{},
+	}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		if pkg, name, ok := goPackage(fd); ok {
+			b := indexBuilder{idx: idx, deprecated: deprecated, pkg: pkg, protoPkg: fd.Package()}
+			b.addMessages(fd.Messages())
+			b.addEnums(fd.Enums())
+			b.addServices(fd.Services(), []string{pkg, pkg + "/" + name + "connect"})
+		}
+		return true
+	})
+	return idx
+}
+
+// goPackage returns the import path and package name of the go_package option
+// of fd, e.g. "example.com/api/v1;apiv1".
+func goPackage(fd protoreflect.FileDescriptor) (string, string, bool) {
+	opts, _ := fd.Options().(*descriptorpb.FileOptions)
+	goPkg := opts.GetGoPackage()
+	if goPkg == "" {
+		return "", "", false
+	}
+	if pkg, name, ok := strings.Cut(goPkg, ";"); ok {
+		return pkg, name, true
+	}
+	return goPkg, strings.ReplaceAll(path.Base(goPkg), "-", "_"), true
+}
+
+type indexBuilder struct {
+	idx        *index
+	deprecated map[protoreflect.FullName]apideprecation.ElementDetails
+	pkg        string
+	protoPkg   protoreflect.FullName
+}
+
+// goName returns the Go name of a message or enum: its full name without the
+// proto package, e.g. "Outer_Inner" for "pkg.Outer.Inner".
+func (b indexBuilder) goName(desc protoreflect.Descriptor) string {
+	name := string(desc.FullName())
+	if b.protoPkg != "" {
+		name = strings.TrimPrefix(name, string(b.protoPkg)+".")
+	}
+	return goCamelCase(name)
+}
+
+// addMessages adds the deprecated fields of messages and of their nested messages.
+func (b indexBuilder) addMessages(messages protoreflect.MessageDescriptors) {
+	for i := range messages.Len() {
+		md := messages.Get(i)
+		if md.IsMapEntry() {
+			continue
+		}
+		goName := b.goName(md)
+		fields := md.Fields()
+		for j := range fields.Len() {
+			fd := fields.Get(j)
+			e, ok := b.deprecated[fd.FullName()]
+			if !ok {
+				continue
+			}
+			b.idx.fields[goKey{pkg: b.pkg, typ: goName, num: int32(fd.Number())}] = e
+			if oneof := fd.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+				wrapper := goName + "_" + goCamelCase(string(fd.Name()))
+				b.idx.fields[goKey{pkg: b.pkg, typ: wrapper, num: int32(fd.Number())}] = e
+			}
+		}
+		b.addEnums(md.Enums())
+		b.addMessages(md.Messages())
+	}
+}
+
+// addEnums adds the deprecated values of enums by the names of their
+// constants, so that non-deprecated aliases of deprecated values (see
+// allow_alias) are not reported. Constants of nested enums are prefixed by the
+// name of the parent message, e.g. "Outer_VALUE", and others by the name of
+// the enum, e.g. "State_STATE_LEGACY".
+func (b indexBuilder) addEnums(enums protoreflect.EnumDescriptors) {
+	for i := range enums.Len() {
+		ed := enums.Get(i)
+		goName, prefix := b.goName(ed), b.goName(ed)
+		if parent, ok := ed.Parent().(protoreflect.MessageDescriptor); ok {
+			prefix = b.goName(parent)
+		}
+		values := ed.Values()
+		for j := range values.Len() {
+			if e, ok := b.deprecated[values.Get(j).FullName()]; ok {
+				b.idx.enumValues[goKey{pkg: b.pkg, typ: goName, name: prefix + "_" + string(values.Get(j).Name())}] = e
+			}
+		}
+	}
+}
+
+// addServices adds the deprecated methods of the client interfaces and
+// implementations of services, e.g. "FooClient" and "fooClient", generated in
+// the packages pkgs.
+func (b indexBuilder) addServices(services protoreflect.ServiceDescriptors, pkgs []string) {
+	for i := range services.Len() {
+		sd := services.Get(i)
+		goName := goCamelCase(string(sd.Name()))
+		clients := []string{goName + "Client", strings.ToLower(goName[:1]) + goName[1:] + "Client"}
+		methods := sd.Methods()
+		for j := range methods.Len() {
+			md := methods.Get(j)
+			e, ok := b.deprecated[md.FullName()]
+			if !ok {
+				continue
+			}
+			for _, pkg := range pkgs {
+				for _, client := range clients {
+					b.idx.methods[goKey{pkg: pkg, typ: client, name: goCamelCase(string(md.Name()))}] = e
+				}
+			}
+		}
+	}
+}
+
+// goCamelCase converts a protobuf name to its Go name, like protoc-gen-go does.
+func goCamelCase(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '.' && i+1 < len(s) && isASCIILower(s[i+1]):
+			// Skip over '.' in ".{{lowercase}}".
+		case c == '.':
+			b = append(b, '_')
+		case c == '_' && (i == 0 || s[i-1] == '.'):
+			// Convert initial '_' to ensure we start with a capital letter.
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && isASCIILower(s[i+1]):
+			// Skip over '_' in "_{{lowercase}}".
+		case isASCIIDigit(c):
+			b = append(b, c)
+		default:
+			// The next word must start with an upper case letter, followed by
+			// the lower case sequence.
+			if isASCIILower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(s) && isASCIILower(s[i+1]); i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+func isASCIILower(c byte) bool { return 'a' <= c && c <= 'z' }
+func isASCIIDigit(c byte) bool { return '0' <= c && c <= '9' }
@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+
+	pb "example.com/api"
+	"example.com/api/pbconnect"
+)
+
+func fields() {
+	r := &pb.Resource{
+		Name:  "n",
+		Title: "t", // want `field testdata.Resource.title is deprecated and stopped working on 2025-03-01: Use display_name instead.`
+	}
+	r.Title = "t" // want `field testdata.Resource.title is deprecated and stopped working on 2025-03-01`
+	r.DisplayName = r.Title
+	_ = []*pb.Resource{{Title: "t"}} // want `field testdata.Resource.title`
+}
+
+func enums(r *pb.Resource) {
+	switch r.State {
+	case pb.State_STATE_ACTIVE:
+	case pb.State_STATE_LEGACY: // want `enum value testdata.STATE_LEGACY is deprecated and stopped working on 2025-04-01: Use STATE_ACTIVE instead.`
+	}
+	_ = pb.Priority_PRIORITY_HIGH
+	_ = pb.Priority_PRIORITY_URGENT // want `enum value testdata.PRIORITY_URGENT is deprecated`
+}
+
+func methods(ctx context.Context, client pb.ResourceServiceClient, connectClient pbconnect.ResourceServiceClient) {
+	_, _ = client.GetResource(ctx, &pb.GetResourceRequest{})
+	_, _ = client.GetResourceLegacy(ctx, &pb.GetResourceRequest{})        // want `method testdata.ResourceService.GetResourceLegacy is deprecated and will stop working on 2025-06-01: Use GetResource instead.`
+	_, _ = connectClient.GetResourceLegacy(ctx, &pb.GetResourceRequest{}) // want `method testdata.ResourceService.GetResourceLegacy`
+}
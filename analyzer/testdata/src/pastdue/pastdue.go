@@ -0,0 +1,12 @@
+package pastdue
+
+import (
+	"context"
+
+	pb "example.com/api"
+)
+
+func calls(ctx context.Context, client pb.ResourceServiceClient) {
+	_, _ = client.GetResourceLegacy(ctx, &pb.GetResourceRequest{})                                // effective on 2025-06-01
+	_, _ = client.GetResource(ctx, &pb.GetResourceRequest{Name: (&pb.Resource{Title: "t"}).Name}) // want `field testdata.Resource.title is deprecated and stopped working on 2025-03-01`
+}
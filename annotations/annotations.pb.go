@@ -24,10 +24,21 @@ const (
 
 type DeprecationDetails struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// The date when this method, service, message or field will stop working (format: YYYY-MM-DD).
+	// The date when this method, service, message, field or oneof will stop working (format: YYYY-MM-DD).
 	EffectiveAt string `protobuf:"bytes,1,opt,name=effective_at,json=effectiveAt,proto3" json:"effective_at,omitempty"`
 	// A description to help users understand the reason for deprecation and suggest alternatives.
-	Description   string `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Description string `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	// Scheduled windows during which calls using the element are rejected before
+	// it stops working, to surface remaining clients.
+	Brownouts []*BrownoutWindow `protobuf:"bytes,3,rep,name=brownouts,proto3" json:"brownouts,omitempty"`
+	// The full name of the successor element, e.g. "pkg.Service.NewMethod" or "pkg.Message.new_field".
+	Replacement string `protobuf:"bytes,4,opt,name=replacement,proto3" json:"replacement,omitempty"`
+	// A URL of the documentation of the deprecation and the migration.
+	DocsUrl string `protobuf:"bytes,5,opt,name=docs_url,json=docsUrl,proto3" json:"docs_url,omitempty"`
+	// The team owning the element, e.g. to route alerts.
+	Owner string `protobuf:"bytes,6,opt,name=owner,proto3" json:"owner,omitempty"`
+	// A ticket tracking the removal of the element, e.g. "API-123".
+	Ticket        string `protobuf:"bytes,7,opt,name=ticket,proto3" json:"ticket,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -76,6 +87,104 @@ func (x *DeprecationDetails) GetDescription() string {
 	return ""
 }
 
+func (x *DeprecationDetails) GetBrownouts() []*BrownoutWindow {
+	if x != nil {
+		return x.Brownouts
+	}
+	return nil
+}
+
+func (x *DeprecationDetails) GetReplacement() string {
+	if x != nil {
+		return x.Replacement
+	}
+	return ""
+}
+
+func (x *DeprecationDetails) GetDocsUrl() string {
+	if x != nil {
+		return x.DocsUrl
+	}
+	return ""
+}
+
+func (x *DeprecationDetails) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *DeprecationDetails) GetTicket() string {
+	if x != nil {
+		return x.Ticket
+	}
+	return ""
+}
+
+type BrownoutWindow struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The start of the window (RFC 3339, e.g. 2025-05-01T10:00:00Z). If empty, the window has already started.
+	Start string `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	// The end of the window (RFC 3339). If empty, the window never ends.
+	End string `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+	// The percentage of calls rejected during the window. Defaults to 100.
+	Percent       *float64 `protobuf:"fixed64,3,opt,name=percent,proto3,oneof" json:"percent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BrownoutWindow) Reset() {
+	*x = BrownoutWindow{}
+	mi := &file_annotations_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BrownoutWindow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BrownoutWindow) ProtoMessage() {}
+
+func (x *BrownoutWindow) ProtoReflect() protoreflect.Message {
+	mi := &file_annotations_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BrownoutWindow.ProtoReflect.Descriptor instead.
+func (*BrownoutWindow) Descriptor() ([]byte, []int) {
+	return file_annotations_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BrownoutWindow) GetStart() string {
+	if x != nil {
+		return x.Start
+	}
+	return ""
+}
+
+func (x *BrownoutWindow) GetEnd() string {
+	if x != nil {
+		return x.End
+	}
+	return ""
+}
+
+func (x *BrownoutWindow) GetPercent() float64 {
+	if x != nil && x.Percent != nil {
+		return *x.Percent
+	}
+	return 0
+}
+
 var file_annotations_proto_extTypes = []protoimpl.ExtensionInfo{
 	{
 		ExtendedType:  (*descriptorpb.ServiceOptions)(nil),
@@ -109,6 +218,30 @@ var file_annotations_proto_extTypes = []protoimpl.ExtensionInfo{
 		Tag:           "bytes,1194,opt,name=field_deprecation_details",
 		Filename:      "annotations.proto",
 	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*string)(nil),
+		Field:         1195,
+		Name:          "deprecation.field_mask_target",
+		Tag:           "bytes,1195,opt,name=field_mask_target",
+		Filename:      "annotations.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.OneofOptions)(nil),
+		ExtensionType: (*DeprecationDetails)(nil),
+		Field:         1194,
+		Name:          "deprecation.oneof_deprecation_details",
+		Tag:           "bytes,1194,opt,name=oneof_deprecation_details",
+		Filename:      "annotations.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.OneofOptions)(nil),
+		ExtensionType: (*bool)(nil),
+		Field:         1195,
+		Name:          "deprecation.oneof_deprecated",
+		Tag:           "varint,1195,opt,name=oneof_deprecated",
+		Filename:      "annotations.proto",
+	},
 	{
 		ExtendedType:  (*descriptorpb.EnumValueOptions)(nil),
 		ExtensionType: (*DeprecationDetails)(nil),
@@ -153,6 +286,27 @@ var (
 	//
 	// optional deprecation.DeprecationDetails field_deprecation_details = 1194;
 	E_FieldDeprecationDetails = &file_annotations_proto_extTypes[3]
+	// Name of the message field, declared in the same message as this
+	// google.protobuf.FieldMask field, whose message the mask paths refer to.
+	// Without it, `update_mask` refers to the only other message field, and other
+	// masks, e.g. `read_mask`, refer to the response message of the method.
+	//
+	// optional string field_mask_target = 1195;
+	E_FieldMaskTarget = &file_annotations_proto_extTypes[4]
+)
+
+// Extension fields to descriptorpb.OneofOptions.
+var (
+	// Contains additional information about the planned deprecation of a oneof.
+	// Used along with `option (deprecation.oneof_deprecated) = true`.
+	//
+	// optional deprecation.DeprecationDetails oneof_deprecation_details = 1194;
+	E_OneofDeprecationDetails = &file_annotations_proto_extTypes[5]
+	// Marks all fields of a oneof as deprecated, since oneofs have no standard
+	// `deprecated` option.
+	//
+	// optional bool oneof_deprecated = 1195;
+	E_OneofDeprecated = &file_annotations_proto_extTypes[6]
 )
 
 // Extension fields to descriptorpb.EnumValueOptions.
@@ -161,21 +315,35 @@ var (
 	// Used along with `[deprecated = true]`.
 	//
 	// optional deprecation.DeprecationDetails enum_value_deprecation_details = 1194;
-	E_EnumValueDeprecationDetails = &file_annotations_proto_extTypes[4]
+	E_EnumValueDeprecationDetails = &file_annotations_proto_extTypes[7]
 )
 
 var File_annotations_proto protoreflect.FileDescriptor
 
 const file_annotations_proto_rawDesc = "" +
 	"\n" +
-	"\x11annotations.proto\x12\vdeprecation\x1a google/protobuf/descriptor.proto\"Y\n" +
+	"\x11annotations.proto\x12\vdeprecation\x1a google/protobuf/descriptor.proto\"\xff\x01\n" +
 	"\x12DeprecationDetails\x12!\n" +
 	"\feffective_at\x18\x01 \x01(\tR\veffectiveAt\x12 \n" +
-	"\vdescription\x18\x02 \x01(\tR\vdescription:\x81\x01\n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x129\n" +
+	"\tbrownouts\x18\x03 \x03(\v2\x1b.deprecation.BrownoutWindowR\tbrownouts\x12 \n" +
+	"\vreplacement\x18\x04 \x01(\tR\vreplacement\x12\x19\n" +
+	"\bdocs_url\x18\x05 \x01(\tR\adocsUrl\x12\x14\n" +
+	"\x05owner\x18\x06 \x01(\tR\x05owner\x12\x16\n" +
+	"\x06ticket\x18\a \x01(\tR\x06ticket\"c\n" +
+	"\x0eBrownoutWindow\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\tR\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\tR\x03end\x12\x1d\n" +
+	"\apercent\x18\x03 \x01(\x01H\x00R\apercent\x88\x01\x01B\n" +
+	"\n" +
+	"\b_percent:\x81\x01\n" +
 	"\x1bservice_deprecation_details\x12\x1f.google.protobuf.ServiceOptions\x18\xaa\t \x01(\v2\x1f.deprecation.DeprecationDetailsR\x19serviceDeprecationDetails:~\n" +
 	"\x1amethod_deprecation_details\x12\x1e.google.protobuf.MethodOptions\x18\xaa\t \x01(\v2\x1f.deprecation.DeprecationDetailsR\x18methodDeprecationDetails:\x81\x01\n" +
 	"\x1bmessage_deprecation_details\x12\x1f.google.protobuf.MessageOptions\x18\xaa\t \x01(\v2\x1f.deprecation.DeprecationDetailsR\x19messageDeprecationDetails:{\n" +
-	"\x19field_deprecation_details\x12\x1d.google.protobuf.FieldOptions\x18\xaa\t \x01(\v2\x1f.deprecation.DeprecationDetailsR\x17fieldDeprecationDetails:\x88\x01\n" +
+	"\x19field_deprecation_details\x12\x1d.google.protobuf.FieldOptions\x18\xaa\t \x01(\v2\x1f.deprecation.DeprecationDetailsR\x17fieldDeprecationDetails:J\n" +
+	"\x11field_mask_target\x12\x1d.google.protobuf.FieldOptions\x18\xab\t \x01(\tR\x0ffieldMaskTarget:{\n" +
+	"\x19oneof_deprecation_details\x12\x1d.google.protobuf.OneofOptions\x18\xaa\t \x01(\v2\x1f.deprecation.DeprecationDetailsR\x17oneofDeprecationDetails:I\n" +
+	"\x10oneof_deprecated\x12\x1d.google.protobuf.OneofOptions\x18\xab\t \x01(\bR\x0foneofDeprecated:\x88\x01\n" +
 	"\x1eenum_value_deprecation_details\x12!.google.protobuf.EnumValueOptions\x18\xaa\t \x01(\v2\x1f.deprecation.DeprecationDetailsR\x1benumValueDeprecationDetailsB\xa4\x01\n" +
 	"\x0fcom.deprecationB\x10AnnotationsProtoP\x01Z3github.com/belo4ya/grpc-api-deprecation/deprecation\xa2\x02\x03DXX\xaa\x02\vDeprecation\xca\x02\vDeprecation\xe2\x02\x17Deprecation\\GPBMetadata\xea\x02\vDeprecationb\x06proto3"
 
@@ -191,31 +359,38 @@ func file_annotations_proto_rawDescGZIP() []byte {
 	return file_annotations_proto_rawDescData
 }
 
-var file_annotations_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_annotations_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_annotations_proto_goTypes = []any{
 	(*DeprecationDetails)(nil),            // 0: deprecation.DeprecationDetails
-	(*descriptorpb.ServiceOptions)(nil),   // 1: google.protobuf.ServiceOptions
-	(*descriptorpb.MethodOptions)(nil),    // 2: google.protobuf.MethodOptions
-	(*descriptorpb.MessageOptions)(nil),   // 3: google.protobuf.MessageOptions
-	(*descriptorpb.FieldOptions)(nil),     // 4: google.protobuf.FieldOptions
-	(*descriptorpb.EnumValueOptions)(nil), // 5: google.protobuf.EnumValueOptions
+	(*BrownoutWindow)(nil),                // 1: deprecation.BrownoutWindow
+	(*descriptorpb.ServiceOptions)(nil),   // 2: google.protobuf.ServiceOptions
+	(*descriptorpb.MethodOptions)(nil),    // 3: google.protobuf.MethodOptions
+	(*descriptorpb.MessageOptions)(nil),   // 4: google.protobuf.MessageOptions
+	(*descriptorpb.FieldOptions)(nil),     // 5: google.protobuf.FieldOptions
+	(*descriptorpb.OneofOptions)(nil),     // 6: google.protobuf.OneofOptions
+	(*descriptorpb.EnumValueOptions)(nil), // 7: google.protobuf.EnumValueOptions
 }
 var file_annotations_proto_depIdxs = []int32{
-	1,  // 0: deprecation.service_deprecation_details:extendee -> google.protobuf.ServiceOptions
-	2,  // 1: deprecation.method_deprecation_details:extendee -> google.protobuf.MethodOptions
-	3,  // 2: deprecation.message_deprecation_details:extendee -> google.protobuf.MessageOptions
-	4,  // 3: deprecation.field_deprecation_details:extendee -> google.protobuf.FieldOptions
-	5,  // 4: deprecation.enum_value_deprecation_details:extendee -> google.protobuf.EnumValueOptions
-	0,  // 5: deprecation.service_deprecation_details:type_name -> deprecation.DeprecationDetails
-	0,  // 6: deprecation.method_deprecation_details:type_name -> deprecation.DeprecationDetails
-	0,  // 7: deprecation.message_deprecation_details:type_name -> deprecation.DeprecationDetails
-	0,  // 8: deprecation.field_deprecation_details:type_name -> deprecation.DeprecationDetails
-	0,  // 9: deprecation.enum_value_deprecation_details:type_name -> deprecation.DeprecationDetails
-	10, // [10:10] is the sub-list for method output_type
-	10, // [10:10] is the sub-list for method input_type
-	5,  // [5:10] is the sub-list for extension type_name
-	0,  // [0:5] is the sub-list for extension extendee
-	0,  // [0:0] is the sub-list for field type_name
+	1,  // 0: deprecation.DeprecationDetails.brownouts:type_name -> deprecation.BrownoutWindow
+	2,  // 1: deprecation.service_deprecation_details:extendee -> google.protobuf.ServiceOptions
+	3,  // 2: deprecation.method_deprecation_details:extendee -> google.protobuf.MethodOptions
+	4,  // 3: deprecation.message_deprecation_details:extendee -> google.protobuf.MessageOptions
+	5,  // 4: deprecation.field_deprecation_details:extendee -> google.protobuf.FieldOptions
+	5,  // 5: deprecation.field_mask_target:extendee -> google.protobuf.FieldOptions
+	6,  // 6: deprecation.oneof_deprecation_details:extendee -> google.protobuf.OneofOptions
+	6,  // 7: deprecation.oneof_deprecated:extendee -> google.protobuf.OneofOptions
+	7,  // 8: deprecation.enum_value_deprecation_details:extendee -> google.protobuf.EnumValueOptions
+	0,  // 9: deprecation.service_deprecation_details:type_name -> deprecation.DeprecationDetails
+	0,  // 10: deprecation.method_deprecation_details:type_name -> deprecation.DeprecationDetails
+	0,  // 11: deprecation.message_deprecation_details:type_name -> deprecation.DeprecationDetails
+	0,  // 12: deprecation.field_deprecation_details:type_name -> deprecation.DeprecationDetails
+	0,  // 13: deprecation.oneof_deprecation_details:type_name -> deprecation.DeprecationDetails
+	0,  // 14: deprecation.enum_value_deprecation_details:type_name -> deprecation.DeprecationDetails
+	15, // [15:15] is the sub-list for method output_type
+	15, // [15:15] is the sub-list for method input_type
+	9,  // [9:15] is the sub-list for extension type_name
+	1,  // [1:9] is the sub-list for extension extendee
+	0,  // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_annotations_proto_init() }
@@ -223,14 +398,15 @@ func file_annotations_proto_init() {
 	if File_annotations_proto != nil {
 		return
 	}
+	file_annotations_proto_msgTypes[1].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_annotations_proto_rawDesc), len(file_annotations_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   1,
-			NumExtensions: 5,
+			NumMessages:   2,
+			NumExtensions: 8,
 			NumServices:   0,
 		},
 		GoTypes:           file_annotations_proto_goTypes,
@@ -0,0 +1,108 @@
+// Package apideprecationtest provides helpers to catch uses of deprecated gRPC
+// APIs in unit tests, rather than in production dashboards.
+//
+// Requests are inspected by an apideprecation.Inspector, with the same cached
+// evaluation plans as the interceptors of apideprecation.Metrics, resolving
+// descriptors in protoregistry.GlobalFiles.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+package apideprecationtest
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	apideprecation "github.com/belo4ya/grpc-api-deprecation"
+)
+
+var inspector = apideprecation.NewInspector(apideprecation.WithFieldMasks())
+
+// TestingT is the subset of testing.TB used by the helpers.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+	FailNow()
+	Cleanup(func())
+}
+
+// Usage is a use of a deprecated method, field, or enum value.
+type Usage struct {
+	// Method is the called method, e.g. "/pkg.Service/Method".
+	Method string
+	// Element is the deprecated element.
+	Element apideprecation.Element
+	// Path is the field path of fields and enum values, e.g. "resource.title".
+	Path string
+	// Via tells how fields and enum values are used: "value" if they are set,
+	// or "field_mask" if they are referenced by a FieldMask. It is empty for
+	// methods.
+	Via string
+	// Warning is the warning that WithWarnings sends for the usage, with the
+	// DeprecationDetails of the element.
+	Warning string
+}
+
+// String returns the warning of the usage, prefixed by the method if set. Via
+// is appended unless it is empty or "value", e.g. " (via field_mask)".
+func (u Usage) String() string {
+	s := u.Warning
+	if u.Via != "" && u.Via != "value" {
+		s += " (via " + u.Via + ")"
+	}
+	if u.Method == "" {
+		return s
+	}
+	return u.Method + ": " + s
+}
+
+// DeprecatedUsages returns the uses of deprecated elements in a call of method,
+// e.g. "/pkg.Service/Method", with the request msg: the method itself, and the
+// deprecated fields and enum values set in msg, including the paths of its
+// FieldMasks. method or msg may be empty to inspect only the other.
+func DeprecatedUsages(method string, msg proto.Message) []Usage {
+	if method == "" {
+		return newUsages(method, inspector.Inspect(msg))
+	}
+	return newUsages(method, inspector.InspectCall(method, msg))
+}
+
+func newUsages(method string, found []apideprecation.Usage) []Usage {
+	usages := make([]Usage, 0, len(found))
+	for _, u := range found {
+		usages = append(usages, Usage{Method: method, Element: u.Element, Path: u.Path, Via: u.Via, Warning: u.Warning()})
+	}
+	return usages
+}
+
+// AssertNoDeprecatedUsage marks the test as failed if a call of method with
+// the request msg uses deprecated elements, see DeprecatedUsages. It reports
+// whether there are none.
+func AssertNoDeprecatedUsage(t TestingT, method string, msg proto.Message) bool {
+	t.Helper()
+	if usages := DeprecatedUsages(method, msg); len(usages) != 0 {
+		t.Errorf("%s", formatUsages(usages))
+		return false
+	}
+	return true
+}
+
+// RequireNoDeprecatedUsage is like AssertNoDeprecatedUsage, but stops the test
+// with FailNow.
+func RequireNoDeprecatedUsage(t TestingT, method string, msg proto.Message) {
+	t.Helper()
+	if !AssertNoDeprecatedUsage(t, method, msg) {
+		t.FailNow()
+	}
+}
+
+// formatUsages renders usages as a test failure message, one usage per line.
+func formatUsages(usages []Usage) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "deprecated API used (%d):", len(usages))
+	for _, u := range usages {
+		sb.WriteString("\n\t")
+		sb.WriteString(u.String())
+	}
+	return sb.String()
+}
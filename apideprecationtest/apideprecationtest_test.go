@@ -0,0 +1,105 @@
+package apideprecationtest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	apideprecation "github.com/belo4ya/grpc-api-deprecation"
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+type fakeT struct {
+	errors   []string
+	failed   bool
+	cleanups []func()
+}
+
+func (t *fakeT) Helper() {}
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+func (t *fakeT) FailNow()         { t.failed = true }
+func (t *fakeT) Cleanup(f func()) { t.cleanups = append(t.cleanups, f) }
+
+func (t *fakeT) finish() {
+	for _, f := range t.cleanups {
+		f()
+	}
+}
+
+func TestDeprecatedUsages(t *testing.T) {
+	usages := DeprecatedUsages("/testdata.ResourceService/UpdateResource", &pb.UpdateResourceRequest{
+		Resource:  &pb.Resource{Title: "t", Children: []*pb.Resource{{State: pb.State_STATE_LEGACY}}},
+		PatchMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+	})
+	assert.Equal(t, []Usage{
+		{ // in patch_mask
+			Method:  "/testdata.ResourceService/UpdateResource",
+			Element: apideprecation.Element{Kind: apideprecation.ElementField, Name: "testdata.Resource.title"},
+			Path:    "resource.title",
+			Via:     "field_mask",
+			Warning: "field resource.title (testdata.Resource.title) is deprecated and will stop working on 2025-03-01: Use display_name instead.",
+		},
+		{
+			Method:  "/testdata.ResourceService/UpdateResource",
+			Element: apideprecation.Element{Kind: apideprecation.ElementEnumValue, Name: "testdata.STATE_LEGACY"},
+			Path:    "resource.children[].state",
+			Via:     "value",
+			Warning: "enum value testdata.STATE_LEGACY in resource.children[].state is deprecated and will stop working on 2025-04-01: Use STATE_ACTIVE instead.",
+		},
+		{
+			Method:  "/testdata.ResourceService/UpdateResource",
+			Element: apideprecation.Element{Kind: apideprecation.ElementField, Name: "testdata.Resource.title"},
+			Path:    "resource.title",
+			Via:     "value",
+			Warning: "field resource.title (testdata.Resource.title) is deprecated and will stop working on 2025-03-01: Use display_name instead.",
+		},
+	}, usages)
+	assert.Equal(t, "/testdata.ResourceService/UpdateResource: "+usages[0].Warning+" (via field_mask)", usages[0].String())
+	assert.Equal(t, "/testdata.ResourceService/UpdateResource: "+usages[2].Warning, usages[2].String())
+
+	usages = DeprecatedUsages("/testdata.ResourceService/GetResourceLegacy", nil)
+	require.Len(t, usages, 1)
+	assert.Equal(t, apideprecation.Element{Kind: apideprecation.ElementMethod, Name: "testdata.ResourceService.GetResourceLegacy"}, usages[0].Element)
+}
+
+func TestRequireNoDeprecatedUsage(t *testing.T) {
+	ft := &fakeT{}
+	RequireNoDeprecatedUsage(ft, "/testdata.ResourceService/UpdateResource", &pb.UpdateResourceRequest{Resource: &pb.Resource{DisplayName: "t"}})
+	assert.False(t, ft.failed)
+	assert.Empty(t, ft.errors)
+
+	RequireNoDeprecatedUsage(ft, "/testdata.ResourceService/UpdateResource", &pb.UpdateResourceRequest{Resource: &pb.Resource{Title: "t"}})
+	assert.True(t, ft.failed)
+	assert.Equal(t, []string{"deprecated API used (1):\n" +
+		"\t/testdata.ResourceService/UpdateResource: field resource.title (testdata.Resource.title) is deprecated and will stop working on 2025-03-01: Use display_name instead.",
+	}, ft.errors)
+}
+
+func TestRecorder(t *testing.T) {
+	ft := &fakeT{}
+	rec := NewRecorder(ft)
+	invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error { return nil }
+	intercept := rec.UnaryClientInterceptor()
+
+	require.NoError(t, intercept(context.Background(), "/testdata.ResourceService/GetResource", &pb.GetResourceRequest{}, &pb.Resource{}, nil, invoker))
+	assert.Empty(t, rec.Usages())
+
+	require.NoError(t, intercept(context.Background(), "/testdata.ResourceService/GetResourceLegacy", &pb.GetResourceRequest{}, &pb.Resource{}, nil, invoker))
+	require.Len(t, rec.Usages(), 1)
+	rec.Reset()
+	ft.finish()
+	assert.Empty(t, ft.errors, "usages are reset")
+
+	require.NoError(t, intercept(context.Background(), "/testdata.ResourceService/GetResourceLegacy", &pb.GetResourceRequest{}, &pb.Resource{}, nil, invoker))
+	ft.finish()
+	assert.Equal(t, []string{"deprecated API used (1):\n" +
+		"\t/testdata.ResourceService/GetResourceLegacy: method testdata.ResourceService.GetResourceLegacy is deprecated and will stop working on 2025-06-01: Use GetResource instead.",
+	}, ft.errors)
+}
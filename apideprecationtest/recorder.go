@@ -0,0 +1,139 @@
+package apideprecationtest
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	apideprecation "github.com/belo4ya/grpc-api-deprecation"
+)
+
+// Recorder provides client and server interceptors that record the uses of
+// deprecated elements in the requests of calls, e.g. in bufconn-based tests.
+// The test fails when it finishes if anything was recorded, listing the usages
+// with their paths and details. Use Reset after checking expected usages.
+type Recorder struct {
+	mu     sync.Mutex
+	usages []Usage
+}
+
+// NewRecorder creates a Recorder that fails t at its cleanup if it has
+// recorded usages.
+func NewRecorder(t TestingT) *Recorder {
+	r := &Recorder{}
+	t.Cleanup(func() {
+		t.Helper()
+		if usages := r.Usages(); len(usages) != 0 {
+			t.Errorf("%s", formatUsages(usages))
+		}
+	})
+	return r
+}
+
+// Usages returns the usages recorded so far, in the order they were recorded.
+func (r *Recorder) Usages() []Usage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.usages)
+}
+
+// Reset drops the recorded usages.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.usages = nil
+}
+
+// recordCall records the usages of a call of method and of its request req,
+// which may be nil.
+func (r *Recorder) recordCall(method string, req any) {
+	msg, _ := req.(proto.Message)
+	r.record(newUsages(method, inspector.InspectCall(method, msg)))
+}
+
+// recordMessage records the usages of a stream message, but not of the method.
+func (r *Recorder) recordMessage(method string, m any) {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return
+	}
+	usages := newUsages(method, inspector.InspectCall(method, msg))
+	r.record(slices.DeleteFunc(usages, func(u Usage) bool { return u.Element.Kind == apideprecation.ElementMethod }))
+}
+
+func (r *Recorder) record(usages []Usage) {
+	if len(usages) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.usages = append(r.usages, usages...)
+}
+
+// UnaryServerInterceptor returns a server interceptor that records the usages
+// of received calls.
+func (r *Recorder) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		r.recordCall(info.FullMethod, req)
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a server interceptor that records the usages
+// of received streams and of every received message.
+func (r *Recorder) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		r.recordCall(info.FullMethod, nil)
+		return handler(srv, &recordingServerStream{ServerStream: ss, recorder: r, method: info.FullMethod})
+	}
+}
+
+// UnaryClientInterceptor returns a client interceptor that records the usages
+// of sent calls.
+func (r *Recorder) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		r.recordCall(method, req)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor returns a client interceptor that records the usages
+// of sent streams and of every sent message.
+func (r *Recorder) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		r.recordCall(method, nil)
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &recordingClientStream{ClientStream: cs, recorder: r, method: method}, nil
+	}
+}
+
+type recordingServerStream struct {
+	grpc.ServerStream
+	recorder *Recorder
+	method   string
+}
+
+func (s *recordingServerStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	s.recorder.recordMessage(s.method, m)
+	return nil
+}
+
+type recordingClientStream struct {
+	grpc.ClientStream
+	recorder *Recorder
+	method   string
+}
+
+func (s *recordingClientStream) SendMsg(m any) error {
+	s.recorder.recordMessage(s.method, m)
+	return s.ClientStream.SendMsg(m)
+}
@@ -0,0 +1,232 @@
+package apideprecation
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	deprecation "github.com/belo4ya/grpc-api-deprecation/annotations"
+)
+
+// BrownoutWindow is a period during which calls using a deprecated element are
+// rejected, to surface the clients that still depend on it before it is removed.
+type BrownoutWindow struct {
+	// Start is the start of the window. If zero, the window has already started.
+	Start time.Time
+	// End is the end of the window. If zero, the window never ends.
+	End time.Time
+	// Percent is the percentage of calls rejected during the window, from 0 to 100.
+	Percent float64
+}
+
+func (w BrownoutWindow) active(now time.Time) bool {
+	return (w.Start.IsZero() || !now.Before(w.Start)) && (w.End.IsZero() || now.Before(w.End))
+}
+
+// BrownoutOption configures the brownouts enabled by WithBrownouts.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+type BrownoutOption func(*brownoutConfig)
+
+type brownoutConfig struct {
+	schedules   map[protoreflect.FullName][]BrownoutWindow
+	annotations bool
+	now         func() time.Time
+	rand        func() float64
+}
+
+// WithBrownouts rejects calls using deprecated methods, fields, and enum
+// values during their brownout windows, set by WithBrownoutSchedule or read
+// from annotations with WithBrownoutAnnotations. Rejected calls fail with a
+// BrownoutError, which has the Unimplemented code for methods and the
+// InvalidArgument code for fields and enum values, and are counted in
+// BrownoutRejectedMetricName in addition to the usage counters.
+//
+// Brownouts are enforced by the server interceptors, ConnectInterceptor on
+// handlers, and HTTPMiddleware. StatsHandler and client-side interceptors only
+// record usage.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithBrownouts(opts ...BrownoutOption) Option {
+	return func(c *config) {
+		cfg := &brownoutConfig{
+			schedules: map[protoreflect.FullName][]BrownoutWindow{},
+			now:       time.Now,
+			rand:      rand.Float64,
+		}
+		for _, opt := range opts {
+			opt(cfg)
+		}
+		c.brownouts = cfg
+	}
+}
+
+// WithBrownoutSchedule sets the brownout windows of a deprecated element by
+// its full name: a method (e.g. "pkg.Service.Method"), a service, applying to
+// all its methods, a field (e.g. "pkg.Message.field"), or an enum value (e.g.
+// "pkg.ENUM_VALUE"). It takes precedence over annotations.
+func WithBrownoutSchedule(element protoreflect.FullName, windows ...BrownoutWindow) BrownoutOption {
+	return func(c *brownoutConfig) {
+		c.schedules[element] = windows
+	}
+}
+
+// WithBrownoutAnnotations reads brownout windows of deprecated elements from
+// the brownouts of their (deprecation.*_deprecation_details) annotations.
+func WithBrownoutAnnotations() BrownoutOption {
+	return func(c *brownoutConfig) {
+		c.annotations = true
+	}
+}
+
+// WithBrownoutClock sets the clock used to match brownout windows. Defaults to time.Now.
+func WithBrownoutClock(now func() time.Time) BrownoutOption {
+	return func(c *brownoutConfig) {
+		c.now = now
+	}
+}
+
+// WithBrownoutRand sets the source of random numbers in [0, 1) used to reject
+// a percentage of calls. Defaults to math/rand/v2.Float64.
+func WithBrownoutRand(rand func() float64) BrownoutOption {
+	return func(c *brownoutConfig) {
+		c.rand = rand
+	}
+}
+
+// rejects reports whether a call using the deprecated element described by
+// desc is rejected by an active brownout window. The first active window
+// applies. Windows are cached in cache by descriptor, see reporters.
+func (c *brownoutConfig) rejects(cache *sync.Map, desc protoreflect.Descriptor) bool {
+	windows := c.windows(cache, desc)
+	if len(windows) == 0 {
+		return false
+	}
+	now := c.now()
+	for _, w := range windows {
+		if w.active(now) {
+			return w.Percent >= 100 || c.rand()*100 < w.Percent
+		}
+	}
+	return false
+}
+
+func (c *brownoutConfig) windows(cache *sync.Map, desc protoreflect.Descriptor) []BrownoutWindow {
+	if v, ok := cache.Load(desc); ok {
+		return v.([]BrownoutWindow)
+	}
+	windows := c.resolveWindows(desc)
+	cache.Store(desc, windows)
+	return windows
+}
+
+func (c *brownoutConfig) resolveWindows(desc protoreflect.Descriptor) []BrownoutWindow {
+	if windows, ok := c.schedules[desc.FullName()]; ok {
+		return windows
+	}
+	if md, ok := desc.(protoreflect.MethodDescriptor); ok {
+		if windows, ok := c.schedules[md.Parent().FullName()]; ok {
+			return windows
+		}
+	}
+	if !c.annotations {
+		return nil
+	}
+
+	var windows []BrownoutWindow
+	for _, w := range DeprecationDetails(desc).GetBrownouts() {
+		window := BrownoutWindow{Percent: 100}
+		if w.Percent != nil {
+			window.Percent = w.GetPercent()
+		}
+		var err error
+		if w.GetStart() != "" {
+			if window.Start, err = time.Parse(time.RFC3339, w.GetStart()); err != nil {
+				continue
+			}
+		}
+		if w.GetEnd() != "" {
+			if window.End, err = time.Parse(time.RFC3339, w.GetEnd()); err != nil {
+				continue
+			}
+		}
+		windows = append(windows, window)
+	}
+	return windows
+}
+
+// BrownoutError is returned for calls rejected by a brownout, see WithBrownouts.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+type BrownoutError struct {
+	// Element is the deprecated element whose brownout rejected the call.
+	Element Element
+	warning string
+	details *deprecation.DeprecationDetails
+}
+
+func (e *BrownoutError) Error() string {
+	return e.warning + " (rejected by a scheduled brownout)"
+}
+
+// Code returns the gRPC code of the error: Unimplemented for methods, and
+// InvalidArgument for fields and enum values.
+func (e *BrownoutError) Code() codes.Code {
+	return rejectionCode(e.Element)
+}
+
+// GRPCStatus implements the interface used by the status package to convert
+// errors to gRPC statuses. See rejectionStatus for its details.
+func (e *BrownoutError) GRPCStatus() *status.Status {
+	return rejectionStatus(e.Code(), e.Error(), "DEPRECATED_API_BROWNOUT", e.Element, e.details)
+}
+
+func rejectionCode(element Element) codes.Code {
+	if element.Kind == ElementMethod {
+		return codes.Unimplemented
+	}
+	return codes.InvalidArgument
+}
+
+// Domain of the ErrorInfo details of rejected calls.
+const errorInfoDomain = "apideprecation"
+
+// rejectionStatus returns the status of a call rejected because of element,
+// with an ErrorInfo detail carrying the element and its deprecation details,
+// and a Help detail linking to the documentation, if set.
+func rejectionStatus(code codes.Code, msg, reason string, element Element, details *deprecation.DeprecationDetails) *status.Status {
+	info := &errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: errorInfoDomain,
+		Metadata: map[string]string{
+			"kind":    string(element.Kind),
+			"element": string(element.Name),
+		},
+	}
+	for key, value := range map[string]string{
+		"effective_at": details.GetEffectiveAt(),
+		"replacement":  details.GetReplacement(),
+		"owner":        details.GetOwner(),
+		"ticket":       details.GetTicket(),
+	} {
+		if value != "" {
+			info.Metadata[key] = value
+		}
+	}
+	errDetails := []protoadapt.MessageV1{info}
+	if docsURL := details.GetDocsUrl(); docsURL != "" {
+		errDetails = append(errDetails, &errdetails.Help{Links: []*errdetails.Help_Link{{
+			Description: "Deprecation of " + string(element.Name),
+			Url:         docsURL,
+		}}})
+	}
+
+	st := status.New(code, msg)
+	if withDetails, err := st.WithDetails(errDetails...); err == nil {
+		return withDetails
+	}
+	return st
+}
@@ -0,0 +1,148 @@
+package apideprecation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	deprecation "github.com/belo4ya/grpc-api-deprecation/annotations"
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestWithBrownouts(t *testing.T) {
+	now := time.Date(2024, 12, 1, 12, 0, 0, 0, time.UTC)
+	rands := []float64{0.4, 0.6}
+	metrics := NewMetrics(WithBrownouts(
+		WithBrownoutSchedule("testdata.ResourceService.GetResourceLegacy",
+			BrownoutWindow{Start: now.Add(-time.Hour), End: now.Add(time.Hour), Percent: 100},
+		),
+		WithBrownoutSchedule("testdata.Resource.title", BrownoutWindow{Start: now.Add(-time.Hour), Percent: 50}),
+		WithBrownoutClock(func() time.Time { return now }),
+		WithBrownoutRand(func() float64 {
+			r := rands[0]
+			rands = rands[1:]
+			return r
+		}),
+	))
+
+	call := func(method string, req proto.Message) (bool, error) {
+		var called bool
+		_, err := metrics.UnaryServerInterceptor()(
+			context.Background(), req,
+			&grpc.UnaryServerInfo{FullMethod: "/testdata.ResourceService/" + method},
+			func(context.Context, any) (any, error) { called = true; return nil, nil },
+		)
+		return called, err
+	}
+
+	called, err := call("GetResourceLegacy", &pb.GetResourceRequest{})
+	assert.False(t, called)
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	assert.Equal(t, "method testdata.ResourceService.GetResourceLegacy is deprecated and will stop working on 2025-06-01: "+
+		"Use GetResource instead. (rejected by a scheduled brownout)", status.Convert(err).Message())
+
+	called, err = call("UpdateResource", &pb.UpdateResourceRequest{Resource: &pb.Resource{Title: "t"}})
+	assert.False(t, called, "rand 0.4 < 50%")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	called, err = call("UpdateResource", &pb.UpdateResourceRequest{Resource: &pb.Resource{Title: "t"}})
+	assert.True(t, called, "rand 0.6 >= 50%")
+	assert.NoError(t, err)
+
+	now = now.Add(2 * time.Hour)
+	called, err = call("GetResourceLegacy", &pb.GetResourceRequest{})
+	assert.True(t, called, "window ended")
+	assert.NoError(t, err)
+
+	c := metrics.brownoutRejected.WithLabelValues("unary", "testdata.ResourceService", "GetResourceLegacy", "method", "testdata.ResourceService.GetResourceLegacy")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c))
+	c = metrics.brownoutRejected.WithLabelValues("unary", "testdata.ResourceService", "UpdateResource", "field", "testdata.Resource.title")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c))
+	c = metrics.deprecatedMethodUsed.WithLabelValues("unary", "testdata.ResourceService", "GetResourceLegacy")
+	assert.Equal(t, float64(2), testutil.ToFloat64(c), "rejected calls are counted as usage")
+}
+
+func TestWithBrownoutAnnotations(t *testing.T) {
+	tests := []struct {
+		name   string
+		now    time.Time
+		rand   float64
+		reject bool
+	}{
+		{name: "before windows", now: time.Date(2024, 11, 30, 0, 0, 0, 0, time.UTC), reject: false},
+		{name: "full window", now: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), rand: 0.99, reject: true},
+		{name: "between windows", now: time.Date(2024, 12, 2, 0, 0, 0, 0, time.UTC), reject: false},
+		{name: "partial window, rejected", now: time.Date(2024, 12, 15, 12, 0, 0, 0, time.UTC), rand: 0.49, reject: true},
+		{name: "partial window, passed", now: time.Date(2024, 12, 15, 12, 0, 0, 0, time.UTC), rand: 0.5, reject: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := NewMetrics(WithBrownouts(
+				WithBrownoutAnnotations(),
+				WithBrownoutClock(func() time.Time { return tt.now }),
+				WithBrownoutRand(func() float64 { return tt.rand }),
+			))
+			_, err := metrics.UnaryServerInterceptor()(
+				context.Background(), &pb.GetResourceRequest{},
+				&grpc.UnaryServerInfo{FullMethod: "/testdata.LegacyResourceService/GetResource"},
+				func(context.Context, any) (any, error) { return nil, nil },
+			)
+			if !tt.reject {
+				assert.NoError(t, err)
+				return
+			}
+			var brownoutErr *BrownoutError
+			require.ErrorAs(t, err, &brownoutErr)
+			assert.Equal(t, Element{Kind: ElementMethod, Name: "testdata.LegacyResourceService.GetResource"}, brownoutErr.Element)
+		})
+	}
+}
+
+func TestWithBrownouts_notEnforced(t *testing.T) {
+	metrics := NewMetrics(WithBrownouts(
+		WithBrownoutSchedule("testdata.ResourceService.GetResourceLegacy", BrownoutWindow{Percent: 100}),
+	))
+
+	// As on the client side and in StatsHandler, which never reject calls.
+	_, err := metrics.observe(context.Background(), &pb.GetResourceRequest{}, newCallMeta("/testdata.ResourceService/GetResourceLegacy", nil), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics.brownoutRejected))
+	c := metrics.deprecatedMethodUsed.WithLabelValues("unary", "testdata.ResourceService", "GetResourceLegacy")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c))
+}
+
+func TestWithBrownoutAnnotations_reload(t *testing.T) {
+	files := func(brownouts ...*deprecation.BrownoutWindow) *protoregistry.Files {
+		fd := reloadFileDescriptor(true)
+		proto.SetExtension(fd.GetService()[0].GetMethod()[0].GetOptions(), deprecation.E_MethodDeprecationDetails,
+			&deprecation.DeprecationDetails{Brownouts: brownouts})
+		files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fd}})
+		require.NoError(t, err)
+		return files
+	}
+	v1, v2 := files(&deprecation.BrownoutWindow{}), files()
+	metrics := NewMetrics(WithFiles(v1), WithBrownouts(WithBrownoutAnnotations()))
+	call := func(files *protoregistry.Files) error {
+		_, err := metrics.UnaryServerInterceptor()(
+			context.Background(), reloadRequest(t, files),
+			&grpc.UnaryServerInfo{FullMethod: "/reload.Service/Get"},
+			func(context.Context, any) (any, error) { return nil, nil },
+		)
+		return err
+	}
+
+	var brownoutErr *BrownoutError
+	assert.ErrorAs(t, call(v1), &brownoutErr)
+	metrics.Reload(v2)
+	assert.NoError(t, call(v2), "the brownout is removed from the reloaded descriptors")
+}
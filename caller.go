@@ -0,0 +1,88 @@
+package apideprecation
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// CallerFromMetadata returns a CallerKeyFunc that identifies callers by the
+// first value of the gRPC metadata key, or of the HTTP header of the same name
+// for HTTPMiddleware and ConnectInterceptor, e.g. "x-client-name".
+func CallerFromMetadata(key string) CallerKeyFunc {
+	return func(ctx context.Context, _ CallMeta) string {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get(key); len(v) != 0 {
+				return v[0]
+			}
+		}
+		if req, ok := ctx.Value(httpRequestKey{}).(*httpRequestInfo); ok {
+			return req.header.Get(key)
+		}
+		return ""
+	}
+}
+
+// CallerFromPeer returns a CallerKeyFunc that identifies callers by the host of
+// their network address.
+func CallerFromPeer() CallerKeyFunc {
+	return func(ctx context.Context, _ CallMeta) string {
+		var addr string
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			addr = p.Addr.String()
+		} else if req, ok := ctx.Value(httpRequestKey{}).(*httpRequestInfo); ok {
+			addr = req.peer
+		}
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			return host
+		}
+		return addr
+	}
+}
+
+// CallerFromTLS returns a CallerKeyFunc that identifies callers by their
+// verified TLS client certificate: its first URI SAN, e.g. a SPIFFE ID, or
+// its subject common name.
+func CallerFromTLS() CallerKeyFunc {
+	return func(ctx context.Context, _ CallMeta) string {
+		var state *tls.ConnectionState
+		if p, ok := peer.FromContext(ctx); ok {
+			if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+				state = &info.State
+			}
+		} else if req, ok := ctx.Value(httpRequestKey{}).(*httpRequestInfo); ok {
+			state = req.tls
+		}
+		if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+			return ""
+		}
+		return certificateIdentity(state.VerifiedChains[0][0])
+	}
+}
+
+func certificateIdentity(cert *x509.Certificate) string {
+	if len(cert.URIs) != 0 {
+		return cert.URIs[0].String()
+	}
+	return cert.Subject.CommonName
+}
+
+type httpRequestKey struct{}
+
+// httpRequestInfo exposes the caller identity of HTTP and connect-go requests
+// to CallerKeyFunc, as gRPC does with metadata and peer.
+type httpRequestInfo struct {
+	header http.Header
+	peer   string
+	tls    *tls.ConnectionState
+}
+
+func withHTTPRequestInfo(ctx context.Context, info *httpRequestInfo) context.Context {
+	return context.WithValue(ctx, httpRequestKey{}, info)
+}
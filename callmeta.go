@@ -5,12 +5,27 @@ import (
 	"google.golang.org/grpc"
 )
 
+// Transports reported in CallMeta.Transport.
+const (
+	TransportGRPC    = "grpc"
+	TransportHTTP    = "http"
+	TransportConnect = "connect"
+)
+
+// Sides reported in CallMeta.Side.
+const (
+	SideServer = "server"
+	SideClient = "client"
+)
+
 // CallMeta contains parsed gRPC method metadata used when labeling metrics.
 type CallMeta struct {
 	FullMethod string
 	Type       string
 	Service    string
 	Method     string
+	Transport  string
+	Side       string
 }
 
 func newCallMeta(fullMethod string, streamInfo *grpc.StreamServerInfo) CallMeta {
@@ -20,5 +35,7 @@ func newCallMeta(fullMethod string, streamInfo *grpc.StreamServerInfo) CallMeta
 		Type:       string(meta.Typ),
 		Service:    meta.Service,
 		Method:     meta.Method,
+		Transport:  TransportGRPC,
+		Side:       SideServer,
 	}
 }
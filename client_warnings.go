@@ -0,0 +1,314 @@
+package apideprecation
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"connectrpc.com/connect"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// WarningsReceivedMetricName is the name of the counter exposed by
+// ClientWarnings, before WithNamespace and WithSubsystem are applied.
+const WarningsReceivedMetricName = "grpc_deprecation_warnings_received_total"
+
+// WarningHandler handles a deprecation warning received from a server for a
+// call to the method (e.g. "/pkg.Service/Method").
+type WarningHandler func(ctx context.Context, method, warning string)
+
+// ClientWarnings provides client interceptors that handle the deprecation
+// warnings sent by servers with WithWarnings, in the WarningHeader response
+// headers and trailers. Like kubectl does with API warnings, every distinct
+// warning is passed to the WarningHandler once per ClientWarnings (up to
+// WithMaxSeenWarnings distinct warnings are remembered), and all of them are
+// counted in WarningsReceivedMetricName.
+// NOTE: Remember to register ClientWarnings object by using prometheus registry, e.g. prometheus.MustRegister(warnings).
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+type ClientWarnings struct {
+	handler     WarningHandler
+	fail        bool
+	counterOpts counterOptions
+
+	maxSeen int
+	mu      sync.Mutex
+	seen    map[string]*list.Element // warning -> warning in lru
+	lru     *list.List               // most recently seen first
+
+	received *prometheus.CounterVec
+}
+
+// ClientWarningsOption configures ClientWarnings.
+type ClientWarningsOption func(*ClientWarnings)
+
+// WithWarningHandler sets the handler of distinct warnings. Defaults to
+// logging them with the standard logger, e.g. "Warning: method ... is deprecated".
+func WithWarningHandler(h WarningHandler) ClientWarningsOption {
+	return func(w *ClientWarnings) {
+		w.handler = h
+	}
+}
+
+// WithFailOnWarning makes calls that received warnings fail with a
+// WarningsError, even if they succeeded, e.g. in tests or CI to catch
+// deprecated API usage early.
+func WithFailOnWarning() ClientWarningsOption {
+	return func(w *ClientWarnings) {
+		w.fail = true
+	}
+}
+
+// WithMaxSeenWarnings bounds the number of distinct warnings remembered to
+// pass each of them to the WarningHandler once. The least recently seen
+// warnings are forgotten first, and are handled again when received next.
+// Defaults to 1000.
+func WithMaxSeenWarnings(n int) ClientWarningsOption {
+	return func(w *ClientWarnings) {
+		w.maxSeen = n
+	}
+}
+
+// WithClientWarningsCounterOptions sets counter options.
+func WithClientWarningsCounterOptions(opts ...CounterOption) ClientWarningsOption {
+	return func(w *ClientWarnings) {
+		w.counterOpts = append(w.counterOpts, opts...)
+	}
+}
+
+// NewClientWarnings creates ClientWarnings.
+func NewClientWarnings(opts ...ClientWarningsOption) *ClientWarnings {
+	w := &ClientWarnings{
+		handler: func(_ context.Context, _, warning string) {
+			log.Printf("Warning: %s", warning)
+		},
+		maxSeen: 1000,
+		seen:    map[string]*list.Element{},
+		lru:     list.New(),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	w.received = prometheus.NewCounterVec(
+		w.counterOpts.apply(prometheus.CounterOpts{
+			Name: WarningsReceivedMetricName,
+			Help: "Count of deprecation warnings received from servers.",
+		}), []string{"grpc_service", "grpc_method"})
+	return w
+}
+
+// Describe implements prometheus.Collector.
+func (w *ClientWarnings) Describe(ch chan<- *prometheus.Desc) {
+	w.received.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (w *ClientWarnings) Collect(ch chan<- prometheus.Metric) {
+	w.received.Collect(ch)
+}
+
+// WarningsError is returned for calls that received deprecation warnings if
+// WithFailOnWarning is enabled.
+type WarningsError struct {
+	// Method is the called method, e.g. "/pkg.Service/Method".
+	Method string
+	// Warnings are the distinct warnings received by the call.
+	Warnings []string
+}
+
+func (e *WarningsError) Error() string {
+	return fmt.Sprintf("%s: deprecated API used: %s", e.Method, strings.Join(e.Warnings, "; "))
+}
+
+// GRPCStatus implements the interface used by the status package to convert
+// errors to gRPC statuses. The status has the FailedPrecondition code.
+func (e *WarningsError) GRPCStatus() *status.Status {
+	return status.New(codes.FailedPrecondition, e.Error())
+}
+
+// handle handles the warnings received by a call. It returns a WarningsError
+// if WithFailOnWarning is enabled and there are any.
+func (w *ClientWarnings) handle(ctx context.Context, method string, warns warnings) error {
+	if len(warns) == 0 {
+		return nil
+	}
+	service, name := "unknown", "unknown"
+	if i := strings.LastIndexByte(method, '/'); i > 0 {
+		service, name = method[1:i], method[i+1:]
+	}
+	for _, warning := range warns {
+		w.received.WithLabelValues(service, name).Inc()
+		if w.firstSeen(warning) {
+			w.handler(ctx, method, warning)
+		}
+	}
+	if w.fail {
+		return &WarningsError{Method: method, Warnings: warns}
+	}
+	return nil
+}
+
+// firstSeen reports whether warning is not among the remembered warnings, and
+// remembers it as the most recently seen one.
+func (w *ClientWarnings) firstSeen(warning string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if e, ok := w.seen[warning]; ok {
+		w.lru.MoveToFront(e)
+		return false
+	}
+	if w.lru.Len() >= max(w.maxSeen, 1) {
+		oldest := w.lru.Back()
+		w.lru.Remove(oldest)
+		delete(w.seen, oldest.Value.(string))
+	}
+	w.seen[warning] = w.lru.PushFront(warning)
+	return true
+}
+
+// UnaryClientInterceptor returns a client interceptor that handles the
+// warnings of unary calls.
+func (w *ClientWarnings) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		var header, trailer metadata.MD
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header), grpc.Trailer(&trailer))...)
+		var warns warnings
+		warns.addMD(header)
+		warns.addMD(trailer)
+		if warnErr := w.handle(ctx, method, warns); err == nil {
+			err = warnErr
+		}
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a client interceptor that handles the
+// warnings of streaming calls, when the stream ends.
+func (w *ClientWarnings) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &warningsClientStream{ClientStream: cs, warnings: w, desc: desc, method: method}, nil
+	}
+}
+
+type warningsClientStream struct {
+	grpc.ClientStream
+	warnings *ClientWarnings
+	desc     *grpc.StreamDesc
+	method   string
+	once     sync.Once
+}
+
+func (s *warningsClientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil && s.desc.ServerStreams {
+		return nil
+	}
+	// The stream has ended, with an error or, without server streaming, after
+	// the single response: headers and trailers are available.
+	s.once.Do(func() {
+		var warns warnings
+		if header, hErr := s.ClientStream.Header(); hErr == nil {
+			warns.addMD(header)
+		}
+		warns.addMD(s.ClientStream.Trailer())
+		if warnErr := s.warnings.handle(s.Context(), s.method, warns); warnErr != nil && (err == nil || errors.Is(err, io.EOF)) {
+			err = warnErr
+		}
+	})
+	return err
+}
+
+// ConnectInterceptor returns a connect.Interceptor that handles the warnings
+// of connect-go client calls: of unary calls when they complete, and of
+// streaming calls when the stream ends.
+func (w *ClientWarnings) ConnectInterceptor() connect.Interceptor {
+	return &connectWarningsInterceptor{warnings: w}
+}
+
+type connectWarningsInterceptor struct {
+	warnings *ClientWarnings
+}
+
+func (i *connectWarningsInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		if !req.Spec().IsClient {
+			return resp, err
+		}
+		var warns warnings
+		if connectErr := new(connect.Error); errors.As(err, &connectErr) {
+			warns.addHeader(connectErr.Meta())
+		} else if err == nil {
+			warns.addHeader(resp.Header())
+			warns.addHeader(resp.Trailer())
+		}
+		if warnErr := i.warnings.handle(ctx, req.Spec().Procedure, warns); err == nil && warnErr != nil {
+			return nil, warnErr
+		}
+		return resp, err
+	}
+}
+
+func (i *connectWarningsInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		return &connectWarningsClientConn{StreamingClientConn: next(ctx, spec), warnings: i.warnings, ctx: ctx}
+	}
+}
+
+func (i *connectWarningsInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
+
+type connectWarningsClientConn struct {
+	connect.StreamingClientConn
+	warnings *ClientWarnings
+	ctx      context.Context
+	once     sync.Once
+}
+
+func (c *connectWarningsClientConn) Receive(m any) error {
+	err := c.StreamingClientConn.Receive(m)
+	if err == nil {
+		return nil
+	}
+	c.once.Do(func() {
+		var warns warnings
+		warns.addHeader(c.ResponseHeader())
+		warns.addHeader(c.ResponseTrailer())
+		if warnErr := c.warnings.handle(c.ctx, c.Spec().Procedure, warns); warnErr != nil && errors.Is(err, io.EOF) {
+			err = warnErr
+		}
+	})
+	return err
+}
+
+func (w *warnings) addMD(md metadata.MD) {
+	for _, v := range md.Get(WarningHeader) {
+		w.add(v)
+	}
+}
+
+func (w *warnings) addHeader(h http.Header) {
+	for _, v := range h.Values(WarningHeader) {
+		w.add(v)
+	}
+}
+
+var (
+	_ prometheus.Collector = (*ClientWarnings)(nil)
+	_ connect.Interceptor  = (*connectWarningsInterceptor)(nil)
+)
@@ -0,0 +1,171 @@
+package apideprecation
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestClientWarnings(t *testing.T) {
+	serverMetrics := NewMetrics(WithWarnings())
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(serverMetrics.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(serverMetrics.StreamServerInterceptor()),
+	)
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "testdata.ResourceService",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "UpdateResource",
+			Handler: func(_ any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				req := &pb.UpdateResourceRequest{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				info := &grpc.UnaryServerInfo{FullMethod: "/testdata.ResourceService/UpdateResource"}
+				return interceptor(ctx, req, info, func(context.Context, any) (any, error) { return &pb.Resource{}, nil })
+			},
+		}},
+		Streams: []grpc.StreamDesc{{
+			StreamName: "WatchResources",
+			Handler: func(_ any, stream grpc.ServerStream) error {
+				for {
+					if err := stream.RecvMsg(&pb.Resource{}); err != nil {
+						return nil
+					}
+				}
+			},
+			ClientStreams: true,
+			ServerStreams: true,
+		}, {
+			StreamName: "ImportResources",
+			Handler: func(_ any, stream grpc.ServerStream) error {
+				for {
+					if err := stream.RecvMsg(&pb.Resource{}); err != nil {
+						return stream.SendMsg(&pb.Resource{})
+					}
+				}
+			},
+			ClientStreams: true,
+		}},
+	}, struct{}{})
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	dial := func(t *testing.T, w *ClientWarnings) *grpc.ClientConn {
+		conn, err := grpc.NewClient("passthrough:///bufconn",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithUnaryInterceptor(w.UnaryClientInterceptor()),
+			grpc.WithStreamInterceptor(w.StreamClientInterceptor()),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		return conn
+	}
+	const titleWarning = `field resource.title (testdata.Resource.title) is deprecated and will stop working on 2025-03-01: Use display_name instead.`
+
+	t.Run("distinct warnings handled once", func(t *testing.T) {
+		var handled []string
+		w := NewClientWarnings(WithWarningHandler(func(_ context.Context, method, warning string) {
+			handled = append(handled, method+": "+warning)
+		}))
+		conn := dial(t, w)
+
+		for range 2 {
+			err := conn.Invoke(context.Background(), "/testdata.ResourceService/UpdateResource",
+				&pb.UpdateResourceRequest{Resource: &pb.Resource{Title: "t"}}, &pb.Resource{})
+			require.NoError(t, err)
+		}
+		err := conn.Invoke(context.Background(), "/testdata.ResourceService/UpdateResource",
+			&pb.UpdateResourceRequest{Resource: &pb.Resource{DisplayName: "t"}}, &pb.Resource{})
+		require.NoError(t, err)
+
+		stream, err := conn.NewStream(context.Background(), &grpc.StreamDesc{ClientStreams: true, ServerStreams: true},
+			"/testdata.ResourceService/WatchResources")
+		require.NoError(t, err)
+		require.NoError(t, stream.SendMsg(&pb.Resource{Title: "t"}))
+		require.NoError(t, stream.CloseSend())
+		assert.ErrorIs(t, stream.RecvMsg(&pb.Resource{}), io.EOF)
+
+		assert.Equal(t, []string{
+			"/testdata.ResourceService/UpdateResource: " + titleWarning,
+			"/testdata.ResourceService/WatchResources: field title (testdata.Resource.title) is deprecated and will stop working on 2025-03-01: Use display_name instead.",
+		}, handled)
+		assert.Equal(t, float64(2), testutil.ToFloat64(w.received.WithLabelValues("testdata.ResourceService", "UpdateResource")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(w.received.WithLabelValues("testdata.ResourceService", "WatchResources")))
+	})
+
+	t.Run("client streaming", func(t *testing.T) {
+		var handled []string
+		w := NewClientWarnings(WithWarningHandler(func(_ context.Context, method, warning string) {
+			handled = append(handled, method+": "+warning)
+		}))
+		conn := dial(t, w)
+
+		stream, err := conn.NewStream(context.Background(), &grpc.StreamDesc{ClientStreams: true},
+			"/testdata.ResourceService/ImportResources")
+		require.NoError(t, err)
+		require.NoError(t, stream.SendMsg(&pb.Resource{Title: "t"}))
+		require.NoError(t, stream.CloseSend())
+		require.NoError(t, stream.RecvMsg(&pb.Resource{})) // as CloseAndRecv
+
+		assert.Equal(t, []string{
+			"/testdata.ResourceService/ImportResources: field title (testdata.Resource.title) is deprecated and will stop working on 2025-03-01: Use display_name instead.",
+		}, handled)
+		assert.Equal(t, float64(1), testutil.ToFloat64(w.received.WithLabelValues("testdata.ResourceService", "ImportResources")))
+
+		failing := dial(t, NewClientWarnings(WithFailOnWarning(), WithWarningHandler(func(context.Context, string, string) {})))
+		stream, err = failing.NewStream(context.Background(), &grpc.StreamDesc{ClientStreams: true},
+			"/testdata.ResourceService/ImportResources")
+		require.NoError(t, err)
+		require.NoError(t, stream.SendMsg(&pb.Resource{Title: "t"}))
+		require.NoError(t, stream.CloseSend())
+		var warnErr *WarningsError
+		assert.ErrorAs(t, stream.RecvMsg(&pb.Resource{}), &warnErr)
+	})
+
+	t.Run("fail on warning", func(t *testing.T) {
+		w := NewClientWarnings(WithFailOnWarning(), WithWarningHandler(func(context.Context, string, string) {}))
+		conn := dial(t, w)
+
+		err := conn.Invoke(context.Background(), "/testdata.ResourceService/UpdateResource",
+			&pb.UpdateResourceRequest{Resource: &pb.Resource{Title: "t"}}, &pb.Resource{})
+		var warnErr *WarningsError
+		require.ErrorAs(t, err, &warnErr)
+		assert.Equal(t, []string{titleWarning}, warnErr.Warnings)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		err = conn.Invoke(context.Background(), "/testdata.ResourceService/UpdateResource",
+			&pb.UpdateResourceRequest{Resource: &pb.Resource{DisplayName: "t"}}, &pb.Resource{})
+		assert.NoError(t, err)
+	})
+}
+
+func TestClientWarnings_maxSeen(t *testing.T) {
+	var handled []string
+	w := NewClientWarnings(
+		WithMaxSeenWarnings(2),
+		WithWarningHandler(func(_ context.Context, _, warning string) { handled = append(handled, warning) }),
+	)
+
+	for _, warning := range []string{"a", "b", "a", "c", "a", "b"} {
+		require.NoError(t, w.handle(context.Background(), "/pkg.Service/Method", warnings{warning}))
+	}
+	assert.Equal(t, []string{"a", "b", "c", "b"}, handled, "b is forgotten when c is seen")
+	assert.Len(t, w.seen, 2)
+}
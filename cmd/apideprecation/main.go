@@ -0,0 +1,59 @@
+// Command apideprecation provides offline tooling for grpc-api-deprecation.
+//
+// Usage:
+//
+//	apideprecation <command> [flags] [args]
+//
+// Commands:
+//
+//	merge       merge usage snapshots from many replicas into one fleet-wide view
+//	monitoring  generate Prometheus alerting rules and a Grafana dashboard
+//	scan        report deprecated, unknown, and reserved fields in stored protobuf data
+//
+// Go code using deprecated elements is reported by apideprecation-lint of the
+// analyzer module.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{name: "merge", usage: "merge usage snapshots from many replicas into one fleet-wide view", run: runMerge},
+	{name: "monitoring", usage: "generate Prometheus alerting rules and a Grafana dashboard", run: runMonitoring},
+	{name: "scan", usage: "report deprecated, unknown, and reserved fields in stored protobuf data", run: runScan},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "apideprecation %s: %v\n", cmd.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	printUsage()
+	os.Exit(2)
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: apideprecation <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-11s %s\n", cmd.name, cmd.usage)
+	}
+}
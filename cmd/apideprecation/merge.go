@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	apideprecation "github.com/belo4ya/grpc-api-deprecation"
+)
+
+func runMerge(args []string) error {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	output := fs.String("o", "", "write the merged snapshot to this file instead of printing a table")
+	byCaller := fs.Bool("by-caller", false, "keep usage per caller instead of aggregating across callers")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: apideprecation merge [flags] snapshot.json...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no snapshots given")
+	}
+
+	snapshots := make([]apideprecation.UsageSnapshot, 0, fs.NArg())
+	for _, path := range fs.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		snapshot, err := apideprecation.ReadUsageSnapshot(f)
+		_ = f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	merged := apideprecation.MergeUsageSnapshots(*byCaller, snapshots...)
+	if *output != "" {
+		return apideprecation.SaveUsageSnapshotFile(*output, merged)
+	}
+	return printUsageTable(os.Stdout, merged)
+}
+
+func printUsageTable(w io.Writer, snapshot apideprecation.UsageSnapshot) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tELEMENT\tCALLER\tCOUNT\tFIRST SEEN\tLAST SEEN")
+	for _, u := range snapshot.Usage {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
+			u.Element.Kind, u.Element.Name, u.Caller, u.Count,
+			u.FirstSeen.UTC().Format(time.RFC3339), u.LastSeen.UTC().Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
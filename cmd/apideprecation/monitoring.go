@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	apideprecation "github.com/belo4ya/grpc-api-deprecation"
+	"github.com/belo4ya/grpc-api-deprecation/monitoring"
+)
+
+func runMonitoring(args []string) error {
+	fs := flag.NewFlagSet("monitoring", flag.ContinueOnError)
+	descriptors := fs.String("descriptors", "", "binary FileDescriptorSet with the API descriptors (required)")
+	name := fs.String("name", "", "PrometheusRule name and dashboard title")
+	namespace := fs.String("namespace", "", "metrics namespace, as set by WithNamespace")
+	subsystem := fs.String("subsystem", "", "metrics subsystem, as set by WithSubsystem")
+	warnBefore := fs.Duration("warn-before", 14*24*time.Hour, "how long before effective_at usage starts to alert")
+	window := fs.Duration("window", time.Hour, "range over which usage is counted in alerts")
+	rulesOut := fs.String("rules", "", "write the PrometheusRule YAML to this file")
+	dashboardOut := fs.String("dashboard", "", "write the Grafana dashboard JSON to this file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: apideprecation monitoring -descriptors set.binpb [-rules rules.yaml] [-dashboard dashboard.json] [flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *descriptors == "" || (*rulesOut == "" && *dashboardOut == "") {
+		fs.Usage()
+		return errors.New("-descriptors and at least one of -rules or -dashboard are required")
+	}
+
+	files, err := apideprecation.LoadDescriptorSets(*descriptors)
+	if err != nil {
+		return err
+	}
+	cfg := monitoring.Config{
+		Name:       *name,
+		Files:      files,
+		Namespace:  *namespace,
+		Subsystem:  *subsystem,
+		WarnBefore: *warnBefore,
+		Window:     *window,
+	}
+
+	if *rulesOut != "" {
+		out, err := monitoring.PrometheusRule(cfg)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*rulesOut, out, 0o644); err != nil {
+			return err
+		}
+	}
+	if *dashboardOut != "" {
+		out, err := monitoring.GrafanaDashboard(cfg)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*dashboardOut, out, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
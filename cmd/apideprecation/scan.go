@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	apideprecation "github.com/belo4ya/grpc-api-deprecation"
+	"github.com/belo4ya/grpc-api-deprecation/scan"
+)
+
+func runScan(args []string) error {
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	descriptors := fs.String("descriptors", "", "binary FileDescriptorSet with the API descriptors (required)")
+	message := fs.String("message", "", "full name of the message type of the payloads (required)")
+	format := fs.String("format", string(scan.FormatDelimited), "payload format: delimited, base64, jsonl, or text")
+	samples := fs.Int("samples", 5, "number of sample offsets to report per finding")
+	maxSize := fs.Int("max-payload-size", scan.DefaultMaxPayloadSize, "maximum size in bytes of a payload or line")
+	jsonOut := fs.Bool("json", false, "print the report as JSON instead of a table")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: apideprecation scan -descriptors set.binpb -message pkg.Message [flags] file...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *descriptors == "" || *message == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("-descriptors, -message, and at least one file are required")
+	}
+
+	files, err := apideprecation.LoadDescriptorSets(*descriptors)
+	if err != nil {
+		return err
+	}
+	s, err := scan.NewScanner(scan.Config{
+		Files:          files,
+		Message:        protoreflect.FullName(*message),
+		Format:         scan.Format(*format),
+		MaxSamples:     *samples,
+		MaxPayloadSize: *maxSize,
+	})
+	if err != nil {
+		return err
+	}
+	for _, path := range fs.Args() {
+		if err := s.ScanFile(path); err != nil {
+			return err
+		}
+	}
+
+	report := s.Report()
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return printScanReport(os.Stdout, report)
+}
+
+func printScanReport(w io.Writer, report scan.Report) error {
+	fmt.Fprintf(w, "%d payloads of %s, %d invalid%s\n\n", report.Payloads, report.Message, report.Invalid, formatSamples(report.InvalidSamples, " at "))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tELEMENT\tPATH\tCOUNT\tSAMPLES")
+	for _, e := range report.Elements {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", e.Element.Kind, e.Element.Name, e.Path, e.Count, formatSamples(e.Samples, ""))
+	}
+	for _, u := range report.Unknown {
+		kind := "unknown"
+		if u.Reserved {
+			kind = "reserved"
+		}
+		field := u.Name
+		if field == "" {
+			field = fmt.Sprintf("#%d", u.Number)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", kind, u.Message, field, u.Count, formatSamples(u.Samples, ""))
+	}
+	return tw.Flush()
+}
+
+// formatSamples renders samples as "file:offset" separated by commas, with the
+// prefix if there are any.
+func formatSamples(samples []scan.Sample, prefix string) string {
+	if len(samples) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(samples))
+	for _, s := range samples {
+		parts = append(parts, fmt.Sprintf("%s:%d", s.File, s.Offset))
+	}
+	return prefix + strings.Join(parts, ", ")
+}
@@ -0,0 +1,172 @@
+package apideprecation
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ConnectInterceptor returns a connect.Interceptor that records deprecated
+// RPC method, field, and enum usage of connect-go calls. On the handler side
+// it observes received request messages and, if WithWarnings is enabled, adds
+// the WarningHeader response headers. On the client side it observes sent
+// request messages. Brownouts and policy rejections (see WithBrownouts and
+// WithPolicy) are enforced on the handler side.
+//
+// Both sides increment the same counters. To use the interceptor on clients
+// and handlers of the same Metrics, tell them apart with SideLabel.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func (m *Metrics) ConnectInterceptor() connect.Interceptor {
+	return &connectInterceptor{metrics: m}
+}
+
+type connectInterceptor struct {
+	metrics *Metrics
+}
+
+func (i *connectInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		msg, ok := req.Any().(proto.Message)
+		if !ok {
+			return next(ctx, req)
+		}
+		observeCtx := ctx
+		if !req.Spec().IsClient {
+			observeCtx = withHTTPRequestInfo(ctx, &httpRequestInfo{header: req.Header(), peer: req.Peer().Addr})
+		}
+		meta := newConnectCallMeta(req.Spec())
+		var enforce *callEnforcement
+		if !req.Spec().IsClient {
+			enforce = &callEnforcement{}
+		}
+		warns, rejectErr := i.metrics.observe(observeCtx, msg, meta, enforce)
+		var resp connect.AnyResponse
+		var err error
+		if rejectErr != nil {
+			err = newConnectRejectionError(rejectErr)
+		} else {
+			if !req.Spec().IsClient {
+				i.metrics.migrate(msg, meta)
+			}
+			resp, err = next(ctx, req)
+		}
+		if len(warns) != 0 && !req.Spec().IsClient {
+			if connectErr := new(connect.Error); errors.As(err, &connectErr) {
+				warns.addTo(connectErr.Meta())
+			} else if err == nil {
+				warns.addTo(resp.Header())
+			}
+		}
+		return resp, err
+	}
+}
+
+func (i *connectInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		return &connectClientConn{
+			StreamingClientConn: next(ctx, spec),
+			metrics:             i.metrics,
+			ctx:                 ctx,
+			meta:                newConnectCallMeta(spec),
+		}
+	}
+}
+
+func (i *connectInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		return next(ctx, &connectHandlerConn{
+			StreamingHandlerConn: conn,
+			metrics:              i.metrics,
+			ctx:                  withHTTPRequestInfo(ctx, &httpRequestInfo{header: conn.RequestHeader(), peer: conn.Peer().Addr}),
+			meta:                 newConnectCallMeta(conn.Spec()),
+		})
+	}
+}
+
+type connectClientConn struct {
+	connect.StreamingClientConn
+	metrics *Metrics
+	ctx     context.Context
+	meta    CallMeta
+}
+
+func (c *connectClientConn) Send(m any) error {
+	if msg, ok := m.(proto.Message); ok {
+		_, _ = c.metrics.observe(c.ctx, msg, c.meta, nil)
+	}
+	return c.StreamingClientConn.Send(m)
+}
+
+type connectHandlerConn struct {
+	connect.StreamingHandlerConn
+	metrics *Metrics
+	ctx     context.Context
+	meta    CallMeta
+	enforce callEnforcement
+}
+
+func (c *connectHandlerConn) Receive(m any) error {
+	if err := c.StreamingHandlerConn.Receive(m); err != nil {
+		return err
+	}
+	if msg, ok := m.(proto.Message); ok {
+		// Headers added after the first Send are not delivered, as with gRPC.
+		warns, err := c.metrics.observe(c.ctx, msg, c.meta, &c.enforce)
+		warns.addTo(c.ResponseHeader())
+		if err != nil {
+			return newConnectRejectionError(err)
+		}
+		c.metrics.migrate(msg, c.meta)
+	}
+	return nil
+}
+
+func newConnectRejectionError(err error) *connect.Error {
+	st := status.Convert(err)
+	connectErr := connect.NewError(connect.Code(st.Code()), err)
+	for _, detail := range st.Proto().GetDetails() {
+		if msg, err := detail.UnmarshalNew(); err == nil {
+			if errDetail, err := connect.NewErrorDetail(msg); err == nil {
+				connectErr.AddDetail(errDetail)
+			}
+		}
+	}
+	return connectErr
+}
+
+func newConnectCallMeta(spec connect.Spec) CallMeta {
+	service, method := "unknown", "unknown"
+	if i := strings.LastIndexByte(spec.Procedure, '/'); i > 0 {
+		service, method = spec.Procedure[1:i], spec.Procedure[i+1:]
+	}
+	var typ interceptors.GRPCType
+	switch spec.StreamType {
+	case connect.StreamTypeClient:
+		typ = interceptors.ClientStream
+	case connect.StreamTypeServer:
+		typ = interceptors.ServerStream
+	case connect.StreamTypeBidi:
+		typ = interceptors.BidiStream
+	default:
+		typ = interceptors.Unary
+	}
+	side := SideServer
+	if spec.IsClient {
+		side = SideClient
+	}
+	return CallMeta{
+		FullMethod: spec.Procedure,
+		Type:       string(typ),
+		Service:    service,
+		Method:     method,
+		Transport:  TransportConnect,
+		Side:       side,
+	}
+}
+
+var _ connect.Interceptor = (*connectInterceptor)(nil)
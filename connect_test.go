@@ -0,0 +1,97 @@
+package apideprecation
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestConnectInterceptor(t *testing.T) {
+	metrics := NewMetrics(WithWarnings(), WithExtraLabels(LabelSet{
+		Method: []Label{TransportLabel(), SideLabel()},
+		Field:  []Label{TransportLabel(), SideLabel()},
+	}))
+	interceptor := connect.WithInterceptors(metrics.ConnectInterceptor())
+
+	mux := http.NewServeMux()
+	mux.Handle("/testdata.ResourceService/GetResourceLegacy", connect.NewUnaryHandler(
+		"/testdata.ResourceService/GetResourceLegacy",
+		func(context.Context, *connect.Request[pb.GetResourceRequest]) (*connect.Response[pb.Resource], error) {
+			return connect.NewResponse(&pb.Resource{}), nil
+		},
+		interceptor,
+	))
+	mux.Handle("/testdata.ResourceService/UpdateResource", connect.NewUnaryHandler(
+		"/testdata.ResourceService/UpdateResource",
+		func(context.Context, *connect.Request[pb.UpdateResourceRequest]) (*connect.Response[pb.Resource], error) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("invalid"))
+		},
+		interceptor,
+	))
+	mux.Handle("/testdata.ResourceService/WatchResources", connect.NewBidiStreamHandler(
+		"/testdata.ResourceService/WatchResources",
+		func(_ context.Context, stream *connect.BidiStream[pb.Resource, pb.Resource]) error {
+			for {
+				if _, err := stream.Receive(); err != nil {
+					return nil
+				}
+			}
+		},
+		interceptor,
+	))
+	srv := httptest.NewUnstartedServer(mux)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	t.Run("deprecated method", func(t *testing.T) {
+		client := connect.NewClient[pb.GetResourceRequest, pb.Resource](srv.Client(), srv.URL+"/testdata.ResourceService/GetResourceLegacy")
+		resp, err := client.CallUnary(context.Background(), connect.NewRequest(&pb.GetResourceRequest{Name: "resources/1"}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"method testdata.ResourceService.GetResourceLegacy is deprecated and will stop working on 2025-06-01: Use GetResource instead.",
+		}, resp.Header().Values(WarningHeader))
+
+		c := metrics.deprecatedMethodUsed.WithLabelValues("unary", "testdata.ResourceService", "GetResourceLegacy", "connect", "server")
+		assert.Equal(t, float64(1), testutil.ToFloat64(c))
+	})
+
+	t.Run("deprecated field with error", func(t *testing.T) {
+		client := connect.NewClient[pb.UpdateResourceRequest, pb.Resource](srv.Client(), srv.URL+"/testdata.ResourceService/UpdateResource")
+		_, err := client.CallUnary(context.Background(), connect.NewRequest(&pb.UpdateResourceRequest{Resource: &pb.Resource{Title: "t"}}))
+		var connectErr *connect.Error
+		require.ErrorAs(t, err, &connectErr)
+		assert.Equal(t, []string{
+			`field resource.title (testdata.Resource.title) is deprecated and will stop working on 2025-03-01: Use display_name instead.`,
+		}, connectErr.Meta().Values(WarningHeader))
+	})
+
+	t.Run("client and handler streams", func(t *testing.T) {
+		metrics.deprecatedFieldUsed.Reset()
+
+		client := connect.NewClient[pb.Resource, pb.Resource](
+			srv.Client(), srv.URL+"/testdata.ResourceService/WatchResources", interceptor)
+		stream := client.CallBidiStream(context.Background())
+		require.NoError(t, stream.Send(&pb.Resource{Title: "a"}))
+		require.NoError(t, stream.Send(&pb.Resource{Title: "b"}))
+		require.NoError(t, stream.CloseRequest())
+		_, err := stream.Receive()
+		assert.Error(t, err) // io.EOF
+		require.NoError(t, stream.CloseResponse())
+		assert.Len(t, stream.ResponseHeader().Values(WarningHeader), 1)
+
+		for _, side := range []string{SideClient, SideServer} {
+			c := metrics.deprecatedFieldUsed.WithLabelValues("bidi_stream", "testdata.ResourceService", "WatchResources", "title", "implicit", "connect", side)
+			assert.Equal(t, float64(2), testutil.ToFloat64(c), side)
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package apideprecation
+
+import (
+	grpcprom "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OccurrencesMetricName is the name of the histogram of occurrences per
+// request, exposed if WithOccurrencesHistogram is enabled, before WithNamespace
+// and WithSubsystem are applied.
+const OccurrencesMetricName = "grpc_deprecated_occurrences_per_request"
+
+// CountingMode selects how often deprecated field and enum value usage is
+// counted. See WithCountingMode.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+type CountingMode int
+
+const (
+	// CountPerOccurrence counts every occurrence of a deprecated field or enum
+	// value, e.g. 50 for a repeated field of 50 messages that each set it.
+	CountPerOccurrence CountingMode = iota
+	// CountPerRequest counts each distinct deprecated element and field path
+	// once per request or stream message, so counters reflect the number of
+	// affected requests rather than the payload shape.
+	CountPerRequest
+)
+
+// WithCountingMode sets how often deprecated field and enum value usage is
+// counted. Defaults to CountPerOccurrence. With CountPerRequest, the labels of
+// the first occurrence are used, and repeated occurrences are neither tracked
+// (see WithUsageTracker and WithHeavyHitters) nor checked for warnings and
+// rejections, which are the same as for the first one.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithCountingMode(mode CountingMode) Option {
+	return func(c *config) {
+		c.counting.mode = mode
+	}
+}
+
+// WithMaxReportsPerRequest caps the number of deprecated field and enum value
+// usages counted per request or stream message to n. Usages beyond the cap are
+// not counted or tracked, but are still warned about and may reject the call.
+// Deprecated methods are always counted. n <= 0 disables the cap, the default.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithMaxReportsPerRequest(n int) Option {
+	return func(c *config) {
+		c.counting.maxReports = n
+	}
+}
+
+// WithOccurrencesHistogram exposes OccurrencesMetricName, the histogram of the
+// number of occurrences of each distinct deprecated field and enum value usage
+// per request or stream message, labeled by "kind", "element", "field", and
+// "via", see WithFieldMasks and WithImplicitEnumDefaults. It
+// counts all occurrences regardless of WithCountingMode and
+// WithMaxReportsPerRequest. WithNamespace, WithSubsystem, and WithConstLabels of
+// WithCounterOptions are applied before opts.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithOccurrencesHistogram(opts ...HistogramOption) Option {
+	return func(c *config) {
+		c.counting.histogram = true
+		c.counting.histogramOpts = opts
+	}
+}
+
+// HistogramOption lets you add options to Histogram metrics using With* funcs.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+type HistogramOption = grpcprom.HistogramOption
+
+// WithHistogramBuckets allows you to specify custom buckets for Histogram metrics.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+var WithHistogramBuckets = grpcprom.WithHistogramBuckets
+
+type countingConfig struct {
+	mode          CountingMode
+	maxReports    int
+	histogram     bool
+	histogramOpts []HistogramOption
+}
+
+// newOccurrencesHistogram builds the histogram of WithOccurrencesHistogram.
+func newOccurrencesHistogram(cfg *config, labels []string) *prometheus.HistogramVec {
+	counterOpts := cfg.counterOpts.apply(prometheus.CounterOpts{})
+	opts := prometheus.HistogramOpts{
+		Namespace:   counterOpts.Namespace,
+		Subsystem:   counterOpts.Subsystem,
+		ConstLabels: counterOpts.ConstLabels,
+		Name:        OccurrencesMetricName,
+		Help:        "Number of occurrences of deprecated field and enum value usages per request.",
+		Buckets:     []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000},
+	}
+	for _, opt := range cfg.counting.histogramOpts {
+		opt(&opts)
+	}
+	return prometheus.NewHistogramVec(opts, labels)
+}
+
+// occurrenceKey is a distinct deprecated field or enum value usage in a request.
+type occurrenceKey struct {
+	element Element
+	path    string
+	via     string
+}
+
+// requestCounts decides which deprecated field and enum value usages of a
+// single request or stream message are counted.
+type requestCounts struct {
+	cfg         *countingConfig
+	reports     int
+	occurrences map[occurrenceKey]int // allocated on the first occurrence if CountPerRequest or the histogram is enabled
+}
+
+func newRequestCounts(cfg *countingConfig) requestCounts {
+	return requestCounts{cfg: cfg}
+}
+
+// add registers an occurrence of element at path, used via via. It reports
+// whether the occurrence is handled at all (false for repeated occurrences
+// with CountPerRequest) and whether it is counted (false beyond the cap).
+func (c *requestCounts) add(element Element, path, via string) (handle, count bool) {
+	if c.cfg.mode == CountPerRequest || c.cfg.histogram {
+		if c.occurrences == nil {
+			c.occurrences = make(map[occurrenceKey]int)
+		}
+		key := occurrenceKey{element: element, path: path, via: via}
+		n := c.occurrences[key]
+		c.occurrences[key] = n + 1
+		if n > 0 && c.cfg.mode == CountPerRequest {
+			return false, false
+		}
+	}
+	if c.cfg.maxReports > 0 {
+		if c.reports >= c.cfg.maxReports {
+			return true, false
+		}
+		c.reports++
+	}
+	return true, true
+}
+
+// observeOccurrences records the occurrences of the request in the histogram
+// of WithOccurrencesHistogram.
+func (m *Metrics) observeOccurrences(meta CallMeta, counts *requestCounts) {
+	if m.occurrences == nil {
+		return
+	}
+	for key, n := range counts.occurrences {
+		m.occurrences.WithLabelValues(meta.Type, meta.Service, meta.Method,
+			string(key.element.Kind), string(key.element.Name), key.path, key.via).Observe(float64(n))
+	}
+}
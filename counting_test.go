@@ -0,0 +1,111 @@
+package apideprecation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestCountingMode(t *testing.T) {
+	req := &pb.Lists{
+		Messages: []*pb.Simple{{FieldDeprecated: 1}, {FieldDeprecated: 1}, {FieldDeprecated: 1}},
+		Enums:    []pb.Enum{pb.Enum_ENUM_DEPRECATED, pb.Enum_ENUM_DEPRECATED},
+	}
+	call := func(metrics *Metrics) {
+		_, err := metrics.UnaryServerInterceptor()(
+			context.Background(), req,
+			&grpc.UnaryServerInfo{FullMethod: "/t.Service/Method"},
+			func(ctx context.Context, req any) (any, error) { return nil, nil },
+		)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		fields float64
+		enums  float64
+	}{
+		{name: "per occurrence", fields: 3, enums: 2},
+		{name: "per request", opts: []Option{WithCountingMode(CountPerRequest)}, fields: 1, enums: 1},
+		{name: "max reports", opts: []Option{WithMaxReportsPerRequest(2)}, fields: 2, enums: 0},
+		{name: "per request with max reports", opts: []Option{WithCountingMode(CountPerRequest), WithMaxReportsPerRequest(1)}, fields: 1, enums: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := NewMetrics(tt.opts...)
+			call(metrics)
+			assert.Equal(t, tt.fields, sumCounters(t, metrics.deprecatedFieldUsed))
+			assert.Equal(t, tt.enums, sumCounters(t, metrics.deprecatedEnumUsed))
+		})
+	}
+}
+
+func TestCountingMode_via(t *testing.T) {
+	metrics := NewMetrics(WithCountingMode(CountPerRequest), WithFieldMasks())
+	_, err := metrics.UnaryServerInterceptor()(
+		context.Background(), &pb.UpdateResourceRequest{
+			Resource:   &pb.Resource{Title: "t"},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+		},
+		&grpc.UnaryServerInfo{FullMethod: "/testdata.ResourceService/UpdateResource"},
+		func(ctx context.Context, req any) (any, error) { return nil, nil },
+	)
+	require.NoError(t, err)
+
+	for _, via := range []string{viaValue, viaFieldMask} {
+		c := metrics.deprecatedFieldUsed.WithLabelValues("unary", "testdata.ResourceService", "UpdateResource", "resource.title", "implicit", via)
+		assert.Equal(t, float64(1), testutil.ToFloat64(c), via)
+	}
+}
+
+func TestWithOccurrencesHistogram(t *testing.T) {
+	metrics := NewMetrics(
+		WithCountingMode(CountPerRequest),
+		WithOccurrencesHistogram(WithHistogramBuckets([]float64{1, 5})),
+		WithCounterOptions(WithNamespace("app")),
+	)
+	_, err := metrics.UnaryServerInterceptor()(
+		context.Background(),
+		&pb.Lists{Messages: []*pb.Simple{{FieldDeprecated: 1}, {FieldDeprecated: 1}, {FieldDeprecated: 1}}},
+		&grpc.UnaryServerInfo{FullMethod: "/t.Service/Method"},
+		func(ctx context.Context, req any) (any, error) { return nil, nil },
+	)
+	require.NoError(t, err)
+
+	assert.NoError(t, testutil.CollectAndCompare(metrics, strings.NewReader(`
+# HELP app_grpc_deprecated_occurrences_per_request Number of occurrences of deprecated field and enum value usages per request.
+# TYPE app_grpc_deprecated_occurrences_per_request histogram
+app_grpc_deprecated_occurrences_per_request_bucket{element="Simple.field_deprecated",field="messages[].field_deprecated",grpc_method="Method",grpc_service="t.Service",grpc_type="unary",kind="field",le="1",via="value"} 0
+app_grpc_deprecated_occurrences_per_request_bucket{element="Simple.field_deprecated",field="messages[].field_deprecated",grpc_method="Method",grpc_service="t.Service",grpc_type="unary",kind="field",le="5",via="value"} 1
+app_grpc_deprecated_occurrences_per_request_bucket{element="Simple.field_deprecated",field="messages[].field_deprecated",grpc_method="Method",grpc_service="t.Service",grpc_type="unary",kind="field",le="+Inf",via="value"} 1
+app_grpc_deprecated_occurrences_per_request_sum{element="Simple.field_deprecated",field="messages[].field_deprecated",grpc_method="Method",grpc_service="t.Service",grpc_type="unary",kind="field",via="value"} 3
+app_grpc_deprecated_occurrences_per_request_count{element="Simple.field_deprecated",field="messages[].field_deprecated",grpc_method="Method",grpc_service="t.Service",grpc_type="unary",kind="field",via="value"} 1
+`), "app_"+OccurrencesMetricName))
+	assert.Equal(t, float64(1), sumCounters(t, metrics.deprecatedFieldUsed))
+}
+
+// sumCounters returns the sum of all counters of c.
+func sumCounters(t *testing.T, c prometheus.Collector) float64 {
+	t.Helper()
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(c))
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	var sum float64
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			sum += m.GetCounter().GetValue()
+		}
+	}
+	return sum
+}
@@ -0,0 +1,99 @@
+package apideprecation
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	deprecation "github.com/belo4ya/grpc-api-deprecation/annotations"
+)
+
+// DeprecationDetails returns the (deprecation.*_deprecation_details) annotation
+// of a service, method, message, field, oneof, or enum value descriptor, or nil
+// if it is not set. A method without its own details inherits the details of
+// its service, and a field those of its deprecated oneof.
+func DeprecationDetails(desc protoreflect.Descriptor) *deprecation.DeprecationDetails {
+	var ext protoreflect.ExtensionType
+	switch desc.(type) {
+	case protoreflect.ServiceDescriptor:
+		ext = deprecation.E_ServiceDeprecationDetails
+	case protoreflect.MethodDescriptor:
+		ext = deprecation.E_MethodDeprecationDetails
+	case protoreflect.MessageDescriptor:
+		ext = deprecation.E_MessageDeprecationDetails
+	case protoreflect.FieldDescriptor:
+		ext = deprecation.E_FieldDeprecationDetails
+	case protoreflect.OneofDescriptor:
+		ext = deprecation.E_OneofDeprecationDetails
+	case protoreflect.EnumValueDescriptor:
+		ext = deprecation.E_EnumValueDeprecationDetails
+	default:
+		return nil
+	}
+
+	opts := desc.Options()
+	if opts != nil && proto.HasExtension(opts, ext) {
+		return proto.GetExtension(opts, ext).(*deprecation.DeprecationDetails)
+	}
+	switch desc := desc.(type) {
+	case protoreflect.MethodDescriptor:
+		if sd, ok := desc.Parent().(protoreflect.ServiceDescriptor); ok {
+			return DeprecationDetails(sd)
+		}
+	case protoreflect.FieldDescriptor:
+		if od := containingOneof(desc); od != nil && isOneofDeprecated(od) {
+			return DeprecationDetails(od)
+		}
+	}
+	return nil
+}
+
+type deprecatedDescriptorKey struct{}
+
+// labelScope is the value of deprecatedDescriptorKey: the deprecated element
+// being recorded and the reporters that found it, caching label values.
+type labelScope struct {
+	desc      protoreflect.Descriptor
+	reporters *reporters
+}
+
+// DeprecatedDescriptor returns the descriptor of the deprecated method, field,
+// or enum value being recorded from the context passed to a LabelValueFunc, or
+// nil outside of it. For a field referenced by a FieldMask because its message
+// type is deprecated (see WithFieldMasks), it is the message descriptor. Pass
+// it to DeprecationDetails to label usage with its deprecation details.
+func DeprecatedDescriptor(ctx context.Context) protoreflect.Descriptor {
+	scope, _ := ctx.Value(deprecatedDescriptorKey{}).(labelScope)
+	return scope.desc
+}
+
+// OwnerLabel returns an "owner" label with the owner of the deprecated element
+// from its DeprecationDetails, e.g. to route alerts to the owning team.
+func OwnerLabel() Label {
+	return detailsLabel("owner", (*deprecation.DeprecationDetails).GetOwner)
+}
+
+// TicketLabel returns a "ticket" label with the ticket tracking the removal of
+// the deprecated element from its DeprecationDetails.
+func TicketLabel() Label {
+	return detailsLabel("ticket", (*deprecation.DeprecationDetails).GetTicket)
+}
+
+// ReplacementLabel returns a "replacement" label with the full name of the
+// successor of the deprecated element from its DeprecationDetails.
+func ReplacementLabel() Label {
+	return detailsLabel("replacement", (*deprecation.DeprecationDetails).GetReplacement)
+}
+
+func detailsLabel(name string, get func(*deprecation.DeprecationDetails) string) Label {
+	return Label{
+		Name: name,
+		Value: func(ctx context.Context, _ proto.Message, _ CallMeta, _ protoreflect.MethodDescriptor, _ protoreflect.FieldDescriptor) string {
+			if desc := DeprecatedDescriptor(ctx); desc != nil {
+				return get(DeprecationDetails(desc))
+			}
+			return ""
+		},
+	}
+}
@@ -0,0 +1,112 @@
+package apideprecation
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	deprecation "github.com/belo4ya/grpc-api-deprecation/annotations"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestExtendedDeprecationDetails(t *testing.T) {
+	metrics := NewMetrics(
+		WithWarnings(),
+		WithExtraLabels(LabelSet{Method: []Label{OwnerLabel(), TicketLabel(), ReplacementLabel()}}),
+		WithBrownouts(WithBrownoutSchedule("testdata.LegacyResourceService", BrownoutWindow{Percent: 100})),
+	)
+	warns, err := metrics.observe(context.Background(), &pb.GetResourceRequest{}, newCallMeta("/testdata.LegacyResourceService/GetResource", nil), &callEnforcement{})
+
+	want := "method testdata.LegacyResourceService.GetResource is deprecated and will stop working on 2025-01-01: " +
+		"Use ResourceService instead. (replacement: testdata.ResourceService, docs: https://example.com/docs/legacy-resource-service)"
+	assert.Equal(t, warnings{want}, warns)
+
+	st := status.Convert(err)
+	require.Len(t, st.Details(), 2)
+	assert.Equal(t, map[string]string{
+		"kind":         "method",
+		"element":      "testdata.LegacyResourceService.GetResource",
+		"effective_at": "2025-01-01",
+		"replacement":  "testdata.ResourceService",
+		"owner":        "team-resources",
+		"ticket":       "API-42",
+	}, st.Details()[0].(*errdetails.ErrorInfo).GetMetadata())
+	assert.Equal(t, "https://example.com/docs/legacy-resource-service", st.Details()[1].(*errdetails.Help).GetLinks()[0].GetUrl())
+
+	c := metrics.deprecatedMethodUsed.WithLabelValues("unary", "testdata.LegacyResourceService", "GetResource", "team-resources", "API-42", "testdata.ResourceService")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c))
+
+	var found bool
+	for _, e := range InventoryDetails(nil) {
+		if e.Name == "testdata.LegacyResourceService.GetResource" {
+			found = true
+			assert.Equal(t, ElementDetails{
+				Element:     Element{Kind: ElementMethod, Name: "testdata.LegacyResourceService.GetResource"},
+				EffectiveAt: "2025-01-01",
+				Description: "Use ResourceService instead.",
+				Replacement: "testdata.ResourceService",
+				DocsURL:     "https://example.com/docs/legacy-resource-service",
+				Owner:       "team-resources",
+				Ticket:      "API-42",
+			}, e)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestWithWarnings_headerValue(t *testing.T) {
+	fd := reloadFileDescriptor(true)
+	proto.SetExtension(fd.GetService()[0].GetMethod()[0].GetOptions(), deprecation.E_MethodDeprecationDetails,
+		&deprecation.DeprecationDetails{Description: "Use Update instead.\n  It is 100% «compatible».\n"})
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fd}})
+	require.NoError(t, err)
+	metrics := NewMetrics(WithFiles(files), WithWarnings())
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(metrics.UnaryServerInterceptor()))
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "reload.Service",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Get",
+			Handler: func(_ any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				req := reloadRequest(t, files)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				info := &grpc.UnaryServerInfo{FullMethod: "/reload.Service/Get"}
+				return interceptor(ctx, req, info, func(context.Context, any) (any, error) { return req, nil })
+			},
+		}},
+	}, struct{}{})
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var header metadata.MD
+	err = conn.Invoke(context.Background(), "/reload.Service/Get", reloadRequest(t, files), reloadRequest(t, files), grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"method reload.Service.Get is deprecated: Use Update instead. It is 100%25 %C2%ABcompatible%C2%BB.",
+	}, header.Get(WarningHeader))
+}
@@ -0,0 +1,52 @@
+package apideprecation
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// WithImplicitEnumDefaults reports deprecated enum values with number 0 in
+// unset enum fields with implicit presence (proto3 fields without `optional`).
+// Such fields cannot tell an explicitly sent default value from an omitted
+// field, so every present message with the field counts as using the default
+// value. Fields with explicit presence are always reported when set to a
+// deprecated value, including 0.
+//
+// If enums are given, the policy applies only to these enum types, otherwise
+// to all enums. It adds the "via" label to the enum counter: "value" for set
+// fields and "implicit_default" for unset fields with a deprecated default.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithImplicitEnumDefaults(enums ...protoreflect.FullName) Option {
+	return func(c *config) {
+		policy := &enumDefaultPolicy{}
+		if len(enums) != 0 {
+			policy.enums = make(map[protoreflect.FullName]struct{}, len(enums))
+			for _, name := range enums {
+				policy.enums[name] = struct{}{}
+			}
+		}
+		c.enumDefault = policy
+	}
+}
+
+// enumDefaultPolicy selects the enums whose deprecated default values are
+// reported for unset implicit presence fields. A nil policy selects none.
+type enumDefaultPolicy struct {
+	enums map[protoreflect.FullName]struct{} // nil means all enums
+}
+
+// implicitDefault returns the deprecated default value reported for fd when it
+// is unset, or nil.
+func (p *enumDefaultPolicy) implicitDefault(
+	fd protoreflect.FieldDescriptor,
+	deprecated map[protoreflect.EnumNumber]protoreflect.EnumValueDescriptor,
+) protoreflect.EnumValueDescriptor {
+	if p == nil || fd.HasPresence() || fd.IsList() || fd.IsMap() {
+		return nil
+	}
+	if p.enums != nil {
+		if _, ok := p.enums[fd.Enum().FullName()]; !ok {
+			return nil
+		}
+	}
+	return deprecated[fd.Default().Enum()]
+}
@@ -0,0 +1,91 @@
+package apideprecation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestWithImplicitEnumDefaults(t *testing.T) {
+	type enumMetric struct {
+		field, value, number, via string
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		req  proto.Message
+		want []enumMetric
+	}{
+		{
+			name: "unset implicit presence field",
+			opts: []Option{WithImplicitEnumDefaults()},
+			req:  &pb.Settings{},
+			want: []enumMetric{{field: "visibility", value: "VISIBILITY_LEGACY_DEFAULT", number: "0", via: "implicit_default"}},
+		},
+		{
+			name: "set implicit presence field",
+			opts: []Option{WithImplicitEnumDefaults()},
+			req:  &pb.Settings{Visibility: pb.Visibility_VISIBILITY_PUBLIC},
+			want: nil,
+		},
+		{
+			name: "explicit presence field and list items",
+			opts: []Option{WithImplicitEnumDefaults()},
+			req: &pb.Settings{
+				Visibility:        pb.Visibility_VISIBILITY_PUBLIC,
+				DefaultVisibility: pb.Visibility_VISIBILITY_LEGACY_DEFAULT.Enum(),
+				Visibilities:      []pb.Visibility{pb.Visibility_VISIBILITY_LEGACY_DEFAULT},
+			},
+			want: []enumMetric{
+				{field: "default_visibility", value: "VISIBILITY_LEGACY_DEFAULT", number: "0", via: "value"},
+				{field: "visibilities", value: "VISIBILITY_LEGACY_DEFAULT", number: "0", via: "value"},
+			},
+		},
+		{
+			name: "policy for other enums",
+			opts: []Option{WithImplicitEnumDefaults(protoreflect.FullName("testdata.State"))},
+			req:  &pb.Settings{},
+			want: nil,
+		},
+		{
+			name: "policy for the enum",
+			opts: []Option{WithImplicitEnumDefaults(protoreflect.FullName("testdata.Visibility"))},
+			req:  &pb.Settings{},
+			want: []enumMetric{{field: "visibility", value: "VISIBILITY_LEGACY_DEFAULT", number: "0", via: "implicit_default"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := NewMetrics(tt.opts...)
+			_, _ = metrics.UnaryServerInterceptor()(
+				context.Background(), tt.req,
+				&grpc.UnaryServerInfo{FullMethod: "/t.Service/Method"},
+				func(context.Context, any) (any, error) { return nil, nil },
+			)
+
+			assert.Equal(t, len(tt.want), testutil.CollectAndCount(metrics.deprecatedEnumUsed))
+			for _, want := range tt.want {
+				c := metrics.deprecatedEnumUsed.WithLabelValues("unary", "t.Service", "Method", want.field, want.value, want.number, want.via)
+				assert.Equal(t, float64(1), testutil.ToFloat64(c), want)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		metrics := NewMetrics()
+		_, _ = metrics.UnaryServerInterceptor()(
+			context.Background(), &pb.Settings{},
+			&grpc.UnaryServerInfo{FullMethod: "/t.Service/Method"},
+			func(context.Context, any) (any, error) { return nil, nil },
+		)
+		assert.Equal(t, 0, testutil.CollectAndCount(metrics.deprecatedEnumUsed))
+	})
+}
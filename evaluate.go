@@ -21,13 +21,18 @@ type evalContext struct {
 
 type (
 	onDeprecatedFieldFunc func(fd protoreflect.FieldDescriptor, fieldFullName, fieldPresence string)
-	onDeprecatedEnumFunc  func(fd protoreflect.FieldDescriptor, fieldFullName, enumValue string, enumNumber int)
+	onDeprecatedEnumFunc  func(fd protoreflect.FieldDescriptor, evd protoreflect.EnumValueDescriptor, fieldFullName, via string)
 )
 
 type evalPlan struct {
 	evaluators []evaluator
 }
 
+// IsEmpty reports whether the plan never reports deprecated usage.
+func (p *evalPlan) IsEmpty() bool {
+	return len(p.evaluators) == 0
+}
+
 func (p *evalPlan) Append(eval evaluator) {
 	p.evaluators = append(p.evaluators, eval)
 }
@@ -63,10 +68,10 @@ type fieldNode struct {
 	presence string
 }
 
-func newFieldNode(fd protoreflect.FieldDescriptor) *fieldNode {
+func newFieldNode(fd protoreflect.FieldDescriptor, render fieldPathRenderer) *fieldNode {
 	return &fieldNode{
 		fd:       fd,
-		pathPart: renderFieldPathPart(fd),
+		pathPart: render.part(fd),
 		presence: presenceKind(fd),
 	}
 }
@@ -89,32 +94,43 @@ func presenceKind(fd protoreflect.FieldDescriptor) string {
 
 // enumNode evaluates a terminal (leaf) field or collection item that contains deprecated Enum values.
 type enumNode struct {
-	fd            protoreflect.FieldDescriptor
-	deprecated    map[protoreflect.EnumNumber]protoreflect.Name
-	fieldPathPart string
+	fd              protoreflect.FieldDescriptor
+	deprecated      map[protoreflect.EnumNumber]protoreflect.EnumValueDescriptor
+	implicitDefault protoreflect.EnumValueDescriptor // reported if the field is unset, see WithImplicitEnumDefaults
+	fieldPathPart   string
 }
 
-func newEnumNode(fd protoreflect.FieldDescriptor, deprecated map[protoreflect.EnumNumber]protoreflect.Name) *enumNode {
-	return &enumNode{fd: fd, deprecated: deprecated, fieldPathPart: renderFieldPathPart(fd)}
+func newEnumNode(
+	fd protoreflect.FieldDescriptor,
+	deprecated map[protoreflect.EnumNumber]protoreflect.EnumValueDescriptor,
+	implicitDefault protoreflect.EnumValueDescriptor,
+	render fieldPathRenderer,
+) *enumNode {
+	return &enumNode{fd: fd, deprecated: deprecated, implicitDefault: implicitDefault, fieldPathPart: render.part(fd)}
 }
 
 func (n *enumNode) Eval(evalCtx evalContext, msg protoreflect.Message, val protoreflect.Value) {
 	if val.IsValid() { // as collection item of listNode, mapNode nested.Eval()
 		enum := val.Enum()
-		if name, ok := n.deprecated[enum]; ok {
-			evalCtx.onDeprecatedEnum(n.fd, evalCtx.fieldPath.Render(), string(name), int(enum))
+		if evd, ok := n.deprecated[enum]; ok {
+			evalCtx.onDeprecatedEnum(n.fd, evd, evalCtx.fieldPath.Render(), viaValue)
 		}
 		return
 	}
 
 	// as message field
 	if !msg.Has(n.fd) {
+		if n.implicitDefault != nil {
+			evalCtx.fieldPath.Push(n.fieldPathPart)
+			evalCtx.onDeprecatedEnum(n.fd, n.implicitDefault, evalCtx.fieldPath.Render(), viaImplicitDefault)
+			evalCtx.fieldPath.Pop()
+		}
 		return
 	}
 	enum := msg.Get(n.fd).Enum()
-	if name, ok := n.deprecated[enum]; ok {
+	if evd, ok := n.deprecated[enum]; ok {
 		evalCtx.fieldPath.Push(n.fieldPathPart)
-		evalCtx.onDeprecatedEnum(n.fd, evalCtx.fieldPath.Render(), string(name), int(enum))
+		evalCtx.onDeprecatedEnum(n.fd, evd, evalCtx.fieldPath.Render(), viaValue)
 		evalCtx.fieldPath.Pop()
 	}
 }
@@ -126,11 +142,11 @@ type messageNode struct {
 	fieldPathPart string
 }
 
-func newMessageNode(fd protoreflect.FieldDescriptor, nested evaluator) *messageNode {
+func newMessageNode(fd protoreflect.FieldDescriptor, nested evaluator, render fieldPathRenderer) *messageNode {
 	return &messageNode{
 		fd:            fd,
 		nested:        nested,
-		fieldPathPart: renderFieldPathPart(fd),
+		fieldPathPart: render.part(fd),
 	}
 }
 
@@ -143,6 +159,26 @@ func (n *messageNode) Eval(evalCtx evalContext, msg protoreflect.Message, _ prot
 	evalCtx.fieldPath.Pop()
 }
 
+// extensionsNode evaluates the populated extension fields of an extendable message.
+type extensionsNode struct {
+	extensions map[protoreflect.FieldNumber]evaluator
+}
+
+func newExtensionsNode(extensions map[protoreflect.FieldNumber]evaluator) *extensionsNode {
+	return &extensionsNode{extensions: extensions}
+}
+
+func (n *extensionsNode) Eval(evalCtx evalContext, msg protoreflect.Message, _ protoreflect.Value) {
+	msg.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if fd.IsExtension() {
+			if eval, ok := n.extensions[fd.Number()]; ok {
+				eval.Eval(evalCtx, msg, protoreflect.Value{})
+			}
+		}
+		return true
+	})
+}
+
 // maxItemsPerCollection limits elements processed in repeated/map fields.
 const maxItemsPerCollection = 50
 
@@ -151,14 +187,18 @@ type listNode struct {
 	fd            protoreflect.FieldDescriptor
 	nested        evaluator
 	fieldPathPart string
+	fieldName     string
+	render        fieldPathRenderer
 	evalItemValue func(evalCtx evalContext, val protoreflect.Value)
 }
 
-func newListNode(fd protoreflect.FieldDescriptor, nested evaluator) *listNode {
+func newListNode(fd protoreflect.FieldDescriptor, nested evaluator, render fieldPathRenderer) *listNode {
 	n := &listNode{
 		fd:            fd,
 		nested:        nested,
-		fieldPathPart: renderFieldPathPart(fd),
+		fieldPathPart: render.part(fd),
+		fieldName:     render.name(fd),
+		render:        render,
 		evalItemValue: nil,
 	}
 
@@ -184,9 +224,15 @@ func (n *listNode) Eval(evalCtx evalContext, msg protoreflect.Message, _ protore
 	list := msg.Get(n.fd).List()
 	for i := range list.Len() {
 		if i >= maxItemsPerCollection {
+			evalCtx.fieldPath.Replace(n.fieldPathPart) // in case the previous item had its index rendered
 			hitMaxItemsPerCollectionInc(evalCtx.typ, evalCtx.service, evalCtx.method, evalCtx.fieldPath.Render(), "repeated", maxItemsPerCollection)
 			break
 		}
+		if i < n.render.maxKeys {
+			evalCtx.fieldPath.Replace(listItemPart(n.fieldName, i))
+		} else if n.render.maxKeys != 0 {
+			evalCtx.fieldPath.Replace(n.fieldPathPart)
+		}
 		n.evalItemValue(evalCtx, list.Get(i))
 	}
 	evalCtx.fieldPath.Pop()
@@ -197,14 +243,18 @@ type mapNode struct {
 	fd            protoreflect.FieldDescriptor
 	nested        evaluator
 	fieldPathPart string
+	fieldName     string
+	render        fieldPathRenderer
 	evalItemValue func(evalCtx evalContext, val protoreflect.Value)
 }
 
-func newMapNode(fd protoreflect.FieldDescriptor, nested evaluator) *mapNode {
+func newMapNode(fd protoreflect.FieldDescriptor, nested evaluator, render fieldPathRenderer) *mapNode {
 	n := &mapNode{
 		fd:            fd,
 		nested:        nested,
-		fieldPathPart: renderFieldPathPart(fd),
+		fieldPathPart: render.part(fd),
+		fieldName:     render.name(fd),
+		render:        render,
 		evalItemValue: nil,
 	}
 
@@ -228,11 +278,17 @@ func (n *mapNode) Eval(evalCtx evalContext, msg protoreflect.Message, _ protoref
 	}
 	evalCtx.fieldPath.Push(n.fieldPathPart)
 	cnt := 0
-	msg.Get(n.fd).Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+	msg.Get(n.fd).Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
 		if cnt >= maxItemsPerCollection {
+			evalCtx.fieldPath.Replace(n.fieldPathPart) // in case the previous item had its key rendered
 			hitMaxItemsPerCollectionInc(evalCtx.typ, evalCtx.service, evalCtx.method, evalCtx.fieldPath.Render(), "map", maxItemsPerCollection)
 			return false
 		}
+		if cnt < n.render.maxKeys {
+			evalCtx.fieldPath.Replace(mapItemPart(n.fieldName, k))
+		} else if n.render.maxKeys != 0 {
+			evalCtx.fieldPath.Replace(n.fieldPathPart)
+		}
 		n.evalItemValue(evalCtx, v)
 		cnt++
 		return true
@@ -3,6 +3,7 @@ package apideprecation_test
 import (
 	"context"
 	"net"
+	"net/http"
 
 	apideprecation "github.com/belo4ya/grpc-api-deprecation"
 	"github.com/prometheus/client_golang/prometheus"
@@ -38,3 +39,18 @@ func Example() {
 
 	_ = srv.Serve(&net.TCPListener{})
 }
+
+func ExampleMetrics_HTTPMiddleware() {
+	// HTTP and gRPC usage share the same series unless the transport is added
+	// as a label.
+	transport := []apideprecation.Label{apideprecation.TransportLabel()}
+
+	metrics := apideprecation.NewMetrics(
+		apideprecation.WithExtraLabels(apideprecation.LabelSet{Method: transport, Field: transport, Enum: transport}),
+	)
+	prometheus.MustRegister(metrics)
+
+	gateway := http.NewServeMux() // e.g. a grpc-gateway runtime.ServeMux
+
+	_ = http.ListenAndServe(":8080", metrics.HTTPMiddleware(gateway))
+}
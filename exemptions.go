@@ -0,0 +1,117 @@
+package apideprecation
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ExemptionOption configures the exemptions enabled by WithExemptions.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+type ExemptionOption func(*exemptionConfig)
+
+type exemptionConfig struct {
+	callerKey  CallerKeyFunc
+	exemptions map[string][]exemption // by caller
+	now        func() time.Time
+}
+
+type exemption struct {
+	elements map[protoreflect.FullName]bool // nil means all elements
+	until    time.Time
+}
+
+// WithExemptions exempts callers, identified by callerKey (see
+// CallerFromMetadata, CallerFromPeer, and CallerFromTLS), from deprecation
+// actions: they get no warnings (see WithWarnings) and are never rejected by
+// brownouts or a policy (see WithBrownouts and WithPolicy).
+//
+// Usage is still recorded, with an "exempt" label set to "true" for exempted
+// usage and "false" otherwise, added to all counters after the "via" label.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithExemptions(callerKey CallerKeyFunc, opts ...ExemptionOption) Option {
+	return func(c *config) {
+		cfg := &exemptionConfig{
+			callerKey:  callerKey,
+			exemptions: map[string][]exemption{},
+			now:        time.Now,
+		}
+		for _, opt := range opts {
+			opt(cfg)
+		}
+		c.exemptions = cfg
+	}
+}
+
+// ExemptCaller exempts the caller from deprecation actions on elements,
+// identified by full name: methods, services, applying to all their methods,
+// fields, and enum values. Without elements, all elements are exempted.
+// The exemption expires at until, unless it is zero.
+func ExemptCaller(caller string, until time.Time, elements ...protoreflect.FullName) ExemptionOption {
+	return func(c *exemptionConfig) {
+		e := exemption{until: until}
+		if len(elements) != 0 {
+			e.elements = make(map[protoreflect.FullName]bool, len(elements))
+			for _, element := range elements {
+				e.elements[element] = true
+			}
+		}
+		c.exemptions[caller] = append(c.exemptions[caller], e)
+	}
+}
+
+// WithExemptionClock sets the clock used to expire exemptions. Defaults to time.Now.
+func WithExemptionClock(now func() time.Time) ExemptionOption {
+	return func(c *exemptionConfig) {
+		c.now = now
+	}
+}
+
+// callExemptions resolves the exemptions of a single call. The caller is
+// resolved once, on the first deprecated element.
+type callExemptions struct {
+	cfg      *exemptionConfig
+	ctx      context.Context
+	meta     CallMeta
+	caller   []exemption
+	resolved bool
+}
+
+// exempt reports whether the caller is exempted for the element described by desc.
+func (c *callExemptions) exempt(desc protoreflect.Descriptor) bool {
+	if c.cfg == nil {
+		return false
+	}
+	if !c.resolved {
+		c.resolved = true
+		if caller := c.cfg.callerKey(c.ctx, c.meta); caller != "" {
+			c.caller = c.cfg.exemptions[caller]
+		}
+	}
+	if len(c.caller) == 0 {
+		return false
+	}
+
+	var service protoreflect.FullName
+	if md, ok := desc.(protoreflect.MethodDescriptor); ok {
+		service = md.Parent().FullName()
+	}
+	now := c.cfg.now()
+	for _, e := range c.caller {
+		if !e.until.IsZero() && !now.Before(e.until) {
+			continue
+		}
+		if e.elements == nil || e.elements[desc.FullName()] || (service != "" && e.elements[service]) {
+			return true
+		}
+	}
+	return false
+}
+
+func exemptLabelValue(exempt bool) string {
+	if exempt {
+		return "true"
+	}
+	return "false"
+}
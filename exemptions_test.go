@@ -0,0 +1,92 @@
+package apideprecation
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestWithExemptions(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	metrics := NewMetrics(
+		WithWarnings(),
+		WithBrownouts(WithBrownoutSchedule("testdata.ResourceService.GetResourceLegacy", BrownoutWindow{Percent: 100})),
+		WithExemptions(CallerFromMetadata("x-client"),
+			ExemptCaller("billing", now.Add(time.Hour), "testdata.ResourceService"),
+			ExemptCaller("search", time.Time{}, "testdata.Resource.title"),
+			WithExemptionClock(func() time.Time { return now }),
+		),
+	)
+	observe := func(caller, method string, req proto.Message) (warnings, error) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-client", caller))
+		return metrics.observe(ctx, req, newCallMeta("/testdata.ResourceService/"+method, nil), &callEnforcement{})
+	}
+
+	warns, err := observe("billing", "GetResourceLegacy", &pb.GetResourceRequest{})
+	assert.NoError(t, err)
+	assert.Empty(t, warns)
+	warns, err = observe("search", "GetResourceLegacy", &pb.GetResourceRequest{})
+	assert.Error(t, err, "exempted for another element")
+	assert.Len(t, warns, 1)
+
+	warns, err = observe("search", "UpdateResource", &pb.UpdateResourceRequest{Resource: &pb.Resource{Title: "t"}})
+	assert.NoError(t, err)
+	assert.Empty(t, warns)
+	warns, _ = observe("billing", "UpdateResource", &pb.UpdateResourceRequest{Resource: &pb.Resource{Title: "t"}})
+	assert.Len(t, warns, 1, "exempted for methods of the service only")
+
+	now = now.Add(time.Hour)
+	_, err = observe("billing", "GetResourceLegacy", &pb.GetResourceRequest{})
+	assert.Error(t, err, "expired")
+
+	c := metrics.deprecatedMethodUsed.WithLabelValues("unary", "testdata.ResourceService", "GetResourceLegacy", "true")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c))
+	c = metrics.deprecatedMethodUsed.WithLabelValues("unary", "testdata.ResourceService", "GetResourceLegacy", "false")
+	assert.Equal(t, float64(2), testutil.ToFloat64(c))
+	c = metrics.deprecatedFieldUsed.WithLabelValues("unary", "testdata.ResourceService", "UpdateResource", "resource.title", "implicit", "true")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c))
+	c = metrics.deprecatedFieldUsed.WithLabelValues("unary", "testdata.ResourceService", "UpdateResource", "resource.title", "implicit", "false")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c))
+}
+
+func TestCallerKeyFuncs(t *testing.T) {
+	ctx := context.Background()
+	httpCtx := withHTTPRequestInfo(ctx, &httpRequestInfo{
+		header: http.Header{"X-Client": {"billing"}},
+		peer:   "10.0.0.2:4321",
+		tls: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{
+			{Subject: pkix.Name{CommonName: "billing.internal"}},
+		}}},
+	})
+
+	assert.Equal(t, "search", CallerFromMetadata("x-client")(metadata.NewIncomingContext(ctx, metadata.Pairs("x-client", "search")), CallMeta{}))
+	assert.Equal(t, "billing", CallerFromMetadata("x-client")(httpCtx, CallMeta{}))
+	assert.Empty(t, CallerFromMetadata("x-client")(ctx, CallMeta{}))
+
+	grpcCtx := peer.NewContext(ctx, &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234},
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{
+			{URIs: []*url.URL{{Scheme: "spiffe", Host: "example.org", Path: "/search"}}, Subject: pkix.Name{CommonName: "search"}},
+		}}}},
+	})
+	assert.Equal(t, "10.0.0.1", CallerFromPeer()(grpcCtx, CallMeta{}))
+	assert.Equal(t, "10.0.0.2", CallerFromPeer()(httpCtx, CallMeta{}))
+	assert.Equal(t, "spiffe://example.org/search", CallerFromTLS()(grpcCtx, CallMeta{}))
+	assert.Equal(t, "billing.internal", CallerFromTLS()(httpCtx, CallMeta{}))
+	assert.Empty(t, CallerFromTLS()(ctx, CallMeta{}))
+}
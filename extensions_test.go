@@ -0,0 +1,71 @@
+package apideprecation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestExtensions(t *testing.T) {
+	linked := &pb.Extendable{}
+	proto.SetExtension(linked, pb.E_LegacyNote, "note")
+	req := &pb.Extendable{Name: proto.String("n"), Child: &pb.Extendable{}}
+	proto.SetExtension(req, pb.E_LegacyNote, "note")
+	proto.SetExtension(req, pb.E_Level, pb.Level_LEVEL_LEGACY)
+	proto.SetExtension(req, pb.E_Levels, []pb.Level{pb.Level_LEVEL_LOW, pb.Level_LEVEL_LEGACY})
+	proto.SetExtension(req.Child, pb.E_Linked, linked)
+
+	// Decode as a server would.
+	b, err := proto.Marshal(req)
+	require.NoError(t, err)
+	req = &pb.Extendable{}
+	require.NoError(t, proto.Unmarshal(b, req))
+
+	observe := func(metrics *Metrics) {
+		_, _ = metrics.UnaryServerInterceptor()(
+			context.Background(), req,
+			&grpc.UnaryServerInfo{FullMethod: "/t.Service/Method"},
+			func(context.Context, any) (any, error) { return nil, nil },
+		)
+	}
+
+	t.Run("global types", func(t *testing.T) {
+		metrics := NewMetrics()
+		observe(metrics)
+
+		assert.Equal(t, 2, testutil.CollectAndCount(metrics.deprecatedFieldUsed))
+		for _, field := range []string{"[testdata.legacy_note]", "child.[testdata.linked].[testdata.legacy_note]"} {
+			c := metrics.deprecatedFieldUsed.WithLabelValues("unary", "t.Service", "Method", field, "explicit")
+			assert.Equal(t, float64(1), testutil.ToFloat64(c), field)
+		}
+		assert.Equal(t, 2, testutil.CollectAndCount(metrics.deprecatedEnumUsed))
+		for _, field := range []string{"[testdata.level]", "[testdata.levels]"} {
+			c := metrics.deprecatedEnumUsed.WithLabelValues("unary", "t.Service", "Method", field, "LEVEL_LEGACY", "2")
+			assert.Equal(t, float64(1), testutil.ToFloat64(c), field)
+		}
+	})
+
+	t.Run("numbers", func(t *testing.T) {
+		metrics := NewMetrics(WithFieldPathStyle(FieldPathNumbers))
+		observe(metrics)
+
+		c := metrics.deprecatedFieldUsed.WithLabelValues("unary", "t.Service", "Method", "2.102.100", "explicit")
+		assert.Equal(t, float64(1), testutil.ToFloat64(c))
+	})
+
+	t.Run("unknown extension types", func(t *testing.T) {
+		metrics := NewMetrics(WithExtensionTypes(new(protoregistry.Types)))
+		observe(metrics)
+
+		assert.Equal(t, 0, testutil.CollectAndCount(metrics.deprecatedFieldUsed))
+		assert.Equal(t, 0, testutil.CollectAndCount(metrics.deprecatedEnumUsed))
+	})
+}
@@ -0,0 +1,197 @@
+package apideprecation
+
+import (
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	deprecation "github.com/belo4ya/grpc-api-deprecation/annotations"
+)
+
+// WithFieldMasks reports deprecated fields referenced by the paths of
+// google.protobuf.FieldMask fields of the request message as deprecated field
+// usage, even if the fields themselves are not set: a client listing a field in
+// `update_mask` or `read_mask` depends on it. Fields whose message type is
+// deprecated are reported as well.
+//
+// Mask paths refer to the message named by the (deprecation.field_mask_target)
+// annotation of the mask field. Without it, `update_mask` refers to the only
+// other message field of the request, masks of List methods refer to the only
+// repeated message field of the response, and other masks refer to the
+// response message. Masks are ignored if their target is ambiguous. The field
+// label is the path of the referenced field in the request, e.g.
+// "resource.title", or in the response, e.g. "title" or "resources[].title".
+// Fields whose message type is deprecated are reported as an ElementMessage.
+//
+// It adds the "via" label to the field counter: "value" for set fields and
+// "field_mask" for fields referenced by masks. Only FieldMask fields of the
+// top-level request message are inspected.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithFieldMasks() Option {
+	return func(c *config) {
+		c.fieldMasks = true
+	}
+}
+
+type fieldMaskReporter struct {
+	files  *protoregistry.Files
+	cache  sync.Map // fullMethod -> []fieldMaskTarget
+	render fieldPathRenderer
+}
+
+// fieldMaskTarget binds a FieldMask field of the request message to the
+// message its paths refer to.
+type fieldMaskTarget struct {
+	fd     protoreflect.FieldDescriptor
+	target protoreflect.MessageDescriptor
+	prefix string // path of the target in the request, e.g. "resource."
+}
+
+func newFieldMaskReporter(files *protoregistry.Files, render fieldPathRenderer) *fieldMaskReporter {
+	return &fieldMaskReporter{files: files, render: render}
+}
+
+func (r *fieldMaskReporter) Report(msg protoreflect.Message, meta CallMeta, onDeprecatedField onDeprecatedFieldFunc) {
+	for _, mask := range r.getOrResolve(meta.FullMethod) {
+		if !msg.Has(mask.fd) {
+			continue
+		}
+		fm := msg.Get(mask.fd).Message() // may be dynamic, e.g. for HTTPMiddleware
+		paths := fm.Get(fm.Descriptor().Fields().ByName("paths")).List()
+		for i := range paths.Len() {
+			if fd, fieldPath := r.resolveDeprecatedPath(mask.target, paths.Get(i).String()); fd != nil {
+				onDeprecatedField(fd, mask.prefix+fieldPath, presenceKind(fd))
+			}
+		}
+	}
+}
+
+func (r *fieldMaskReporter) getOrResolve(fullMethod string) []fieldMaskTarget {
+	if v, ok := r.cache.Load(fullMethod); ok {
+		return v.([]fieldMaskTarget)
+	}
+	targets := r.resolveTargets(fullMethod)
+	r.cache.Store(fullMethod, targets)
+	return targets
+}
+
+func (r *fieldMaskReporter) resolveTargets(fullMethod string) []fieldMaskTarget {
+	desc, err := r.files.FindDescriptorByName(fullMethodToName(fullMethod))
+	if err != nil {
+		return nil
+	}
+	md, ok := desc.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil
+	}
+
+	var targets []fieldMaskTarget
+	fields := md.Input().Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if !isFieldMask(fd) {
+			continue
+		}
+		if target, ok := r.resolveTarget(md, fd); ok {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+func (r *fieldMaskReporter) resolveTarget(md protoreflect.MethodDescriptor, fd protoreflect.FieldDescriptor) (fieldMaskTarget, bool) {
+	fields := md.Input().Fields()
+	if name := proto.GetExtension(fd.Options(), deprecation.E_FieldMaskTarget).(string); name != "" {
+		if target := fields.ByName(protoreflect.Name(name)); isSingularMessage(target) {
+			return fieldMaskTarget{fd: fd, target: target.Message(), prefix: r.render.name(target) + "."}, true
+		}
+		return fieldMaskTarget{}, false
+	}
+
+	if fd.Name() != "update_mask" {
+		return r.resolveResponseTarget(md, fd)
+	}
+	var target protoreflect.FieldDescriptor
+	for i := range fields.Len() {
+		if f := fields.Get(i); isSingularMessage(f) && !isFieldMask(f) {
+			if target != nil { // ambiguous
+				return fieldMaskTarget{}, false
+			}
+			target = f
+		}
+	}
+	if target == nil {
+		return fieldMaskTarget{}, false
+	}
+	return fieldMaskTarget{fd: fd, target: target.Message(), prefix: r.render.name(target) + "."}, true
+}
+
+// resolveResponseTarget binds a mask to the response message, or for List
+// methods, e.g. ListResources, to the only repeated message field of the
+// response, e.g. `repeated Resource resources`.
+func (r *fieldMaskReporter) resolveResponseTarget(md protoreflect.MethodDescriptor, fd protoreflect.FieldDescriptor) (fieldMaskTarget, bool) {
+	if !strings.HasPrefix(string(md.Name()), "List") {
+		return fieldMaskTarget{fd: fd, target: md.Output()}, true
+	}
+	var target protoreflect.FieldDescriptor
+	fields := md.Output().Fields()
+	for i := range fields.Len() {
+		if f := fields.Get(i); f.IsList() && f.Kind() == protoreflect.MessageKind {
+			if target != nil { // ambiguous
+				return fieldMaskTarget{}, false
+			}
+			target = f
+		}
+	}
+	if target == nil {
+		return fieldMaskTarget{}, false
+	}
+	return fieldMaskTarget{fd: fd, target: target.Message(), prefix: r.render.part(target) + "."}, true
+}
+
+// resolveDeprecatedPath returns the first deprecated field on a mask path,
+// e.g. "labels.values", and the rendered path up to it, e.g. "labels". Fields
+// whose message type is deprecated count as deprecated. Paths through repeated
+// and map fields end there.
+func (r *fieldMaskReporter) resolveDeprecatedPath(md protoreflect.MessageDescriptor, path string) (protoreflect.FieldDescriptor, string) {
+	var rendered strings.Builder
+	for name := range strings.SplitSeq(path, ".") {
+		if md == nil {
+			return nil, ""
+		}
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return nil, ""
+		}
+		if rendered.Len() > 0 {
+			rendered.WriteByte('.')
+		}
+		rendered.WriteString(r.render.name(fd))
+		if isFieldDeprecated(fd) || (fd.Message() != nil && !fd.IsMap() && isMessageDeprecated(fd.Message())) {
+			return fd, rendered.String()
+		}
+
+		md = nil
+		if isSingularMessage(fd) {
+			md = fd.Message()
+		}
+	}
+	return nil, ""
+}
+
+func isFieldMask(fd protoreflect.FieldDescriptor) bool {
+	return isSingularMessage(fd) && fd.Message().FullName() == "google.protobuf.FieldMask"
+}
+
+func isSingularMessage(fd protoreflect.FieldDescriptor) bool {
+	return fd != nil && fd.Kind() == protoreflect.MessageKind && !fd.IsList() && !fd.IsMap()
+}
+
+func isMessageDeprecated(md protoreflect.MessageDescriptor) bool {
+	opts, ok := md.Options().(*descriptorpb.MessageOptions)
+	return ok && opts.GetDeprecated()
+}
@@ -0,0 +1,116 @@
+package apideprecation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestWithFieldMasks(t *testing.T) {
+	type fieldMetric struct {
+		field, presence, via string
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		req    proto.Message
+		want   []fieldMetric
+	}{
+		{
+			name:   "update_mask refers to the only message field",
+			method: "UpdateResource",
+			req: &pb.UpdateResourceRequest{
+				Resource:   &pb.Resource{DisplayName: "d"},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"display_name", "title"}},
+			},
+			want: []fieldMetric{{field: "resource.title", presence: "implicit", via: "field_mask"}},
+		},
+		{
+			name:   "set field referenced by update_mask",
+			method: "UpdateResource",
+			req: &pb.UpdateResourceRequest{
+				Resource:   &pb.Resource{Title: "t"},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+			},
+			want: []fieldMetric{
+				{field: "resource.title", presence: "implicit", via: "field_mask"},
+				{field: "resource.title", presence: "implicit", via: "value"},
+			},
+		},
+		{
+			name:   "field_mask_target annotation",
+			method: "UpdateResource",
+			req: &pb.UpdateResourceRequest{
+				PatchMask: &fieldmaskpb.FieldMask{Paths: []string{"labels.values"}},
+			},
+			want: []fieldMetric{{field: "resource.labels", presence: "explicit", via: "field_mask"}},
+		},
+		{
+			name:   "read_mask refers to the response",
+			method: "GetResource",
+			req: &pb.GetResourceRequest{
+				ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "title", "labels"}},
+			},
+			want: []fieldMetric{
+				{field: "labels", presence: "explicit", via: "field_mask"},
+				{field: "title", presence: "implicit", via: "field_mask"},
+			},
+		},
+		{
+			name:   "read_mask of a List method refers to the repeated field of the response",
+			method: "ListResources",
+			req: &pb.ListResourcesRequest{
+				ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "title", "next_page_token"}},
+			},
+			want: []fieldMetric{{field: "resources[].title", presence: "implicit", via: "field_mask"}},
+		},
+		{
+			name:   "unknown and not deprecated paths",
+			method: "GetResource",
+			req: &pb.GetResourceRequest{
+				ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"unknown", "name.title", "children.title", "state"}},
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := NewMetrics(WithFieldMasks())
+			_, _ = metrics.UnaryServerInterceptor()(
+				context.Background(), tt.req,
+				&grpc.UnaryServerInfo{FullMethod: "/testdata.ResourceService/" + tt.method},
+				func(context.Context, any) (any, error) { return nil, nil },
+			)
+
+			assert.Equal(t, len(tt.want), testutil.CollectAndCount(metrics.deprecatedFieldUsed))
+			for _, want := range tt.want {
+				c := metrics.deprecatedFieldUsed.WithLabelValues("unary", "testdata.ResourceService", tt.method, want.field, want.presence, want.via)
+				assert.Equal(t, float64(1), testutil.ToFloat64(c), want)
+			}
+		})
+	}
+}
+
+func TestWithFieldMasks_deprecatedMessage(t *testing.T) {
+	tracker := NewUsageTracker()
+	metrics := NewMetrics(WithFieldMasks(), WithUsageTracker(tracker))
+	_, _ = metrics.UnaryServerInterceptor()(
+		context.Background(), &pb.GetResourceRequest{ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"labels"}}},
+		&grpc.UnaryServerInfo{FullMethod: "/testdata.ResourceService/GetResource"},
+		func(context.Context, any) (any, error) { return nil, nil },
+	)
+
+	usage := tracker.Usage()
+	if assert.Len(t, usage, 1) {
+		assert.Equal(t, Element{Kind: ElementMessage, Name: "testdata.LegacyLabels"}, usage[0].Element)
+	}
+	assert.Contains(t, Inventory(nil), usage[0].Element)
+}
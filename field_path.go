@@ -1,14 +1,71 @@
 package apideprecation
 
 import (
+	"strconv"
 	"strings"
 	"sync"
 
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
+// FieldPathStyle selects how fields are named in the field paths reported in
+// the field label.
+type FieldPathStyle int
+
+const (
+	// FieldPathNames renders proto field names, e.g. "resource.children[].display_name",
+	// and extensions as "[pkg.ext]".
+	FieldPathNames FieldPathStyle = iota
+	// FieldPathJSONNames renders JSON field names, e.g. "resource.children[].displayName",
+	// and extensions as "[pkg.ext]".
+	FieldPathJSONNames
+	// FieldPathNumbers renders field numbers, which are stable across renames, e.g. "1.4[].2".
+	FieldPathNumbers
+	// FieldPathFullNames renders fully qualified field names, e.g.
+	// "pkg.UpdateResourceRequest.resource.pkg.Resource.display_name".
+	FieldPathFullNames
+)
+
+// WithFieldPathStyle sets how fields are named in the field label. Defaults to
+// FieldPathNames. Sites and the monitoring package assume FieldPathNames.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithFieldPathStyle(style FieldPathStyle) Option {
+	return func(c *config) {
+		c.fieldPath.style = style
+	}
+}
+
+// WithFieldPathKeys renders the concrete index or map key of the first n items
+// of repeated and map fields in the field label, e.g. "children[0].title" or
+// `states{"a"}`, instead of "children[].title" and "states{}". Later items are
+// rendered without keys. List indices add at most n label values per field,
+// but map keys are arbitrary client-supplied strings: every distinct key adds
+// a label value to the field and enum value counters and to the histogram of
+// WithOccurrencesHistogram, so the cardinality is unbounded. Map iteration
+// order is random as well. Use it for debugging only, not in production.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithFieldPathKeys(n int) Option {
+	return func(c *config) {
+		c.fieldPath.maxKeys = n
+	}
+}
+
+// fieldPathRenderer renders field path parts according to the configured
+// style. Parts are rendered when evaluation plans are built, so that the hot
+// path only joins them.
+type fieldPathRenderer struct {
+	style   FieldPathStyle
+	maxKeys int
+}
+
 func renderFieldPathPart(fd protoreflect.FieldDescriptor) string {
-	name := string(fd.Name())
+	return fieldPathRenderer{}.part(fd)
+}
+
+// part renders the path part of a field, with the "[]" or "{}" suffix for
+// repeated and map fields.
+func (r fieldPathRenderer) part(fd protoreflect.FieldDescriptor) string {
+	name := r.name(fd)
 	if fd.IsList() {
 		return name + "[]"
 	}
@@ -18,6 +75,36 @@ func renderFieldPathPart(fd protoreflect.FieldDescriptor) string {
 	return name
 }
 
+func (r fieldPathRenderer) name(fd protoreflect.FieldDescriptor) string {
+	if fd.IsExtension() && (r.style == FieldPathNames || r.style == FieldPathJSONNames) {
+		return "[" + string(fd.FullName()) + "]"
+	}
+	switch r.style {
+	case FieldPathJSONNames:
+		return fd.JSONName()
+	case FieldPathNumbers:
+		return strconv.Itoa(int(fd.Number()))
+	case FieldPathFullNames:
+		return string(fd.FullName())
+	default:
+		return string(fd.Name())
+	}
+}
+
+// listItemPart renders the path part of the i-th item of a repeated field,
+// e.g. "children[0]".
+func listItemPart(name string, i int) string {
+	return name + "[" + strconv.Itoa(i) + "]"
+}
+
+// mapItemPart renders the path part of a map field item, e.g. `states{"a"}`.
+func mapItemPart(name string, key protoreflect.MapKey) string {
+	if s, ok := key.Interface().(string); ok {
+		return name + "{" + strconv.Quote(s) + "}"
+	}
+	return name + "{" + key.String() + "}"
+}
+
 var fieldPathPool = sync.Pool{
 	New: func() any { return &fieldPath{parts: make([]string, 0, 8)} },
 }
@@ -43,13 +130,18 @@ func (p *fieldPath) Pop() {
 	p.parts = p.parts[:len(p.parts)-1]
 }
 
+// Replace replaces the last part.
+func (p *fieldPath) Replace(part string) {
+	p.parts[len(p.parts)-1] = part
+}
+
 func (p *fieldPath) Render() string {
 	size := len(p.parts) - 1 // +dots, e.g. a.b.c
 	cut := false
 	for i, part := range p.parts {
 		size += len(part)
 		if i == len(p.parts)-1 {
-			if v := part[len(part)-1]; v == ']' || v == '}' {
+			if strings.HasSuffix(part, "[]") || strings.HasSuffix(part, "{}") {
 				cut = true
 				size -= 2
 			}
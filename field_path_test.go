@@ -0,0 +1,106 @@
+package apideprecation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestFieldPathRendering(t *testing.T) {
+	req := &pb.UpdateResourceRequest{
+		Resource: &pb.Resource{
+			Children: []*pb.Resource{{Title: "a"}, {}, {Title: "c"}},
+			States:   map[string]pb.State{"a": pb.State_STATE_LEGACY},
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+	}
+
+	type fieldMetric struct {
+		val        float64
+		field, via string
+	}
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		fields []fieldMetric
+		enum   string
+	}{
+		{
+			name: "names",
+			opts: nil,
+			fields: []fieldMetric{
+				{val: 2, field: "resource.children[].title", via: "value"},
+				{val: 1, field: "resource.title", via: "field_mask"},
+			},
+			enum: "resource.states",
+		},
+		{
+			name: "json names",
+			opts: []Option{WithFieldPathStyle(FieldPathJSONNames)},
+			fields: []fieldMetric{
+				{val: 2, field: "resource.children[].title", via: "value"},
+				{val: 1, field: "resource.title", via: "field_mask"},
+			},
+			enum: "resource.states",
+		},
+		{
+			name: "numbers",
+			opts: []Option{WithFieldPathStyle(FieldPathNumbers)},
+			fields: []fieldMetric{
+				{val: 2, field: "1.4[].101", via: "value"},
+				{val: 1, field: "1.101", via: "field_mask"},
+			},
+			enum: "1.5",
+		},
+		{
+			name: "full names",
+			opts: []Option{WithFieldPathStyle(FieldPathFullNames)},
+			fields: []fieldMetric{
+				{val: 2, field: "testdata.UpdateResourceRequest.resource.testdata.Resource.children[].testdata.Resource.title", via: "value"},
+				{val: 1, field: "testdata.UpdateResourceRequest.resource.testdata.Resource.title", via: "field_mask"},
+			},
+			enum: "testdata.UpdateResourceRequest.resource.testdata.Resource.states",
+		},
+		{
+			name: "keys",
+			opts: []Option{WithFieldPathKeys(1)},
+			fields: []fieldMetric{
+				{val: 1, field: "resource.children[0].title", via: "value"},
+				{val: 1, field: "resource.children[].title", via: "value"},
+				{val: 1, field: "resource.title", via: "field_mask"},
+			},
+			enum: `resource.states{"a"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := NewMetrics(append(tt.opts, WithFieldMasks())...)
+			_, _ = metrics.UnaryServerInterceptor()(
+				context.Background(), req,
+				&grpc.UnaryServerInfo{FullMethod: "/testdata.ResourceService/UpdateResource"},
+				func(context.Context, any) (any, error) { return nil, nil },
+			)
+
+			assert.Equal(t, len(tt.fields), testutil.CollectAndCount(metrics.deprecatedFieldUsed))
+			for _, want := range tt.fields {
+				c := metrics.deprecatedFieldUsed.WithLabelValues("unary", "testdata.ResourceService", "UpdateResource", want.field, "implicit", want.via)
+				assert.Equal(t, want.val, testutil.ToFloat64(c), want)
+			}
+			c := metrics.deprecatedEnumUsed.WithLabelValues("unary", "testdata.ResourceService", "UpdateResource", tt.enum, "STATE_LEGACY", "2")
+			assert.Equal(t, float64(1), testutil.ToFloat64(c))
+			assert.Equal(t, 1, testutil.CollectAndCount(metrics.deprecatedEnumUsed))
+		})
+	}
+
+	fields := (&pb.AllInclusive{}).ProtoReflect().Descriptor().Fields()
+	render := fieldPathRenderer{style: FieldPathJSONNames}
+	assert.Equal(t, "scalarOptionalDeprecated", render.part(fields.ByName("scalar_optional_deprecated")))
+	assert.Equal(t, "listsDeprecated", render.part(fields.ByName("lists_deprecated")))
+}
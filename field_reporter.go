@@ -2,20 +2,30 @@ package apideprecation
 
 import (
 	"maps"
+	"slices"
 	"sync"
 	"sync/atomic"
 
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 type fieldReporter struct {
-	mu    sync.Mutex                // serializes cache writes
-	cache atomic.Pointer[planCache] // copy-on-write cache
+	mu             sync.Mutex                // serializes cache writes
+	cache          atomic.Pointer[planCache] // copy-on-write cache
+	render         fieldPathRenderer
+	enumDefault    *enumDefaultPolicy
+	extensionTypes *protoregistry.Types
 }
 
-func newFieldReporter(seedDesc []protoreflect.MessageDescriptor) *fieldReporter {
-	r := &fieldReporter{}
+func newFieldReporter(
+	seedDesc []protoreflect.MessageDescriptor,
+	render fieldPathRenderer,
+	enumDefault *enumDefaultPolicy,
+	extensionTypes *protoregistry.Types,
+) *fieldReporter {
+	r := &fieldReporter{render: render, enumDefault: enumDefault, extensionTypes: extensionTypes}
 	cache := make(planCache, len(seedDesc))
 	for _, desc := range seedDesc {
 		r.buildPlan(desc, cache)
@@ -58,79 +68,185 @@ func (r *fieldReporter) loadOrBuildPlan(md protoreflect.MessageDescriptor) *eval
 	return plan
 }
 
+// buildPlan builds the plan of md and of its nested messages into cache.
 func (r *fieldReporter) buildPlan(md protoreflect.MessageDescriptor, cache planCache) *evalPlan {
-	if plan, ok := cache[md]; ok {
+	b := &planBuild{cache: cache, built: map[*evalPlan]bool{}}
+	plan := r.build(md, b)
+	b.prune()
+	return plan
+}
+
+func (r *fieldReporter) build(md protoreflect.MessageDescriptor, b *planBuild) *evalPlan {
+	if plan, ok := b.cache[md]; ok {
 		return plan
 	}
 	plan := &evalPlan{}
-	cache[md] = plan
-	r.processFields(md, plan, cache)
+	b.cache[md] = plan
+	b.built[plan] = true
+	r.processFields(md, plan, b)
 	return plan
 }
 
-func (r *fieldReporter) processFields(md protoreflect.MessageDescriptor, plan *evalPlan, cache planCache) {
-	fields := md.Fields()
-	for i := range fields.Len() {
-		fd := fields.Get(i)
+// planBuild holds the plans built by a single buildPlan call. Their emptiness
+// is only known once all of them are built, since messages may be recursive.
+type planBuild struct {
+	cache planCache
+	built map[*evalPlan]bool
+}
+
+// mayReport reports whether a node evaluating the nested plan is needed. Plans
+// of the build are nested unconditionally, and pruned afterward if empty.
+func (b *planBuild) mayReport(nested *evalPlan) bool {
+	return b.built[nested] || !nested.IsEmpty()
+}
 
-		if isFieldDeprecated(fd) {
-			plan.Append(newFieldNode(fd))
-			continue
+// prune removes the nodes of the built plans whose nested plans never report
+// deprecated usage, e.g. of recursive messages without deprecated fields.
+func (b *planBuild) prune() {
+	// Find the plans that report usage directly or through other plans, up to
+	// a fixpoint, as cycles of plans reporting nothing are otherwise non-empty.
+	live := map[*evalPlan]bool{}
+	for changed := true; changed; {
+		changed = false
+		for plan := range b.built {
+			if !live[plan] && slices.ContainsFunc(plan.evaluators, func(eval evaluator) bool { return b.live(eval, live) }) {
+				live[plan] = true
+				changed = true
+			}
 		}
+	}
+	for plan := range b.built {
+		plan.evaluators = slices.DeleteFunc(plan.evaluators, func(eval evaluator) bool {
+			if n, ok := eval.(*extensionsNode); ok {
+				maps.DeleteFunc(n.extensions, func(_ protoreflect.FieldNumber, eval evaluator) bool { return !b.live(eval, live) })
+				return len(n.extensions) == 0
+			}
+			return !b.live(eval, live)
+		})
+	}
+}
 
-		if fd.IsMap() {
-			mv := fd.MapValue()
-			switch mv.Kind() {
-			case protoreflect.MessageKind:
-				if nested := r.buildPlan(mv.Message(), cache); len(nested.evaluators) != 0 {
-					plan.Append(newMapNode(fd, nested))
-				}
-			case protoreflect.EnumKind:
-				if deprecated := collectDeprecatedEnumValues(mv.Enum()); len(deprecated) != 0 {
-					plan.Append(newMapNode(fd, newEnumNode(fd, deprecated)))
-				}
+// live reports whether eval may report deprecated usage, given the live plans
+// of the build found so far.
+func (b *planBuild) live(eval evaluator, live map[*evalPlan]bool) bool {
+	var nested evaluator
+	switch n := eval.(type) {
+	case *messageNode:
+		nested = n.nested
+	case *listNode:
+		nested = n.nested
+	case *mapNode:
+		nested = n.nested
+	case *extensionsNode:
+		for _, eval := range n.extensions {
+			if b.live(eval, live) {
+				return true
 			}
-			continue
 		}
+		return false
+	default:
+		return true
+	}
+	plan, ok := nested.(*evalPlan)
+	if !ok { // e.g. an enumNode of a collection
+		return true
+	}
+	if b.built[plan] {
+		return live[plan]
+	}
+	return !plan.IsEmpty()
+}
 
-		if fd.IsList() {
-			switch fd.Kind() {
-			case protoreflect.MessageKind:
-				if nested := r.buildPlan(fd.Message(), cache); len(nested.evaluators) != 0 {
-					plan.Append(newListNode(fd, nested))
-				}
-			case protoreflect.EnumKind:
-				if deprecated := collectDeprecatedEnumValues(fd.Enum()); len(deprecated) != 0 {
-					plan.Append(newListNode(fd, newEnumNode(fd, deprecated)))
-				}
+func (r *fieldReporter) processFields(md protoreflect.MessageDescriptor, plan *evalPlan, b *planBuild) {
+	fields := md.Fields()
+	for i := range fields.Len() {
+		if eval := r.buildFieldEvaluator(fields.Get(i), b); eval != nil {
+			plan.Append(eval)
+		}
+	}
+
+	if md.ExtensionRanges().Len() == 0 {
+		return
+	}
+	extensions := map[protoreflect.FieldNumber]evaluator{}
+	r.extensionTypes.RangeExtensionsByMessage(md.FullName(), func(xt protoreflect.ExtensionType) bool {
+		xd := xt.TypeDescriptor()
+		if eval := r.buildFieldEvaluator(xd, b); eval != nil {
+			extensions[xd.Number()] = eval
+		}
+		return true
+	})
+	if len(extensions) != 0 {
+		plan.Append(newExtensionsNode(extensions))
+	}
+}
+
+// buildFieldEvaluator returns the evaluator of a field or extension, or nil if
+// it cannot contain deprecated fields or enum values.
+func (r *fieldReporter) buildFieldEvaluator(fd protoreflect.FieldDescriptor, b *planBuild) evaluator {
+	if isFieldDeprecated(fd) {
+		return newFieldNode(fd, r.render)
+	}
+
+	if fd.IsMap() {
+		mv := fd.MapValue()
+		switch mv.Kind() {
+		case protoreflect.MessageKind:
+			if nested := r.build(mv.Message(), b); b.mayReport(nested) {
+				return newMapNode(fd, nested, r.render)
+			}
+		case protoreflect.EnumKind:
+			if deprecated := collectDeprecatedEnumValues(mv.Enum()); len(deprecated) != 0 {
+				return newMapNode(fd, newEnumNode(fd, deprecated, nil, r.render), r.render)
 			}
-			continue
 		}
+		return nil
+	}
 
+	if fd.IsList() {
 		switch fd.Kind() {
 		case protoreflect.MessageKind:
-			if nested := r.buildPlan(fd.Message(), cache); len(nested.evaluators) != 0 {
-				plan.Append(newMessageNode(fd, nested))
+			if nested := r.build(fd.Message(), b); b.mayReport(nested) {
+				return newListNode(fd, nested, r.render)
 			}
 		case protoreflect.EnumKind:
 			if deprecated := collectDeprecatedEnumValues(fd.Enum()); len(deprecated) != 0 {
-				plan.Append(newEnumNode(fd, deprecated))
+				return newListNode(fd, newEnumNode(fd, deprecated, nil, r.render), r.render)
 			}
 		}
+		return nil
 	}
+
+	switch fd.Kind() {
+	case protoreflect.MessageKind:
+		if nested := r.build(fd.Message(), b); b.mayReport(nested) {
+			return newMessageNode(fd, nested, r.render)
+		}
+	case protoreflect.EnumKind:
+		if deprecated := collectDeprecatedEnumValues(fd.Enum()); len(deprecated) != 0 {
+			implicitDefault := r.enumDefault.implicitDefault(fd, deprecated)
+			return newEnumNode(fd, deprecated, implicitDefault, r.render)
+		}
+	}
+	return nil
 }
 
+// isFieldDeprecated reports whether the field is deprecated itself or is a
+// member of a deprecated oneof.
 func isFieldDeprecated(fd protoreflect.FieldDescriptor) bool {
-	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
-	return ok && opts.GetDeprecated()
+	if opts, ok := fd.Options().(*descriptorpb.FieldOptions); ok && opts.GetDeprecated() {
+		return true
+	}
+	od := containingOneof(fd)
+	return od != nil && isOneofDeprecated(od)
 }
 
-func collectDeprecatedEnumValues(ed protoreflect.EnumDescriptor) map[protoreflect.EnumNumber]protoreflect.Name {
-	deprecated := map[protoreflect.EnumNumber]protoreflect.Name{}
+func collectDeprecatedEnumValues(ed protoreflect.EnumDescriptor) map[protoreflect.EnumNumber]protoreflect.EnumValueDescriptor {
+	deprecated := map[protoreflect.EnumNumber]protoreflect.EnumValueDescriptor{}
 	enums := ed.Values()
 	for i := range enums.Len() {
 		if evd := enums.Get(i); isEnumValueDeprecated(evd) {
-			deprecated[evd.Number()] = evd.Name()
+			deprecated[evd.Number()] = evd
 		}
 	}
 	return deprecated
@@ -0,0 +1,30 @@
+package apideprecation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestFieldReporter_recursivePlans(t *testing.T) {
+	r := newFieldReporter(nil, fieldPathRenderer{}, nil, protoregistry.GlobalTypes)
+	plan := func(msg interface{ ProtoReflect() protoreflect.Message }) *evalPlan {
+		return r.loadOrBuildPlan(msg.ProtoReflect().Descriptor())
+	}
+
+	// Recursive messages without deprecated fields.
+	assert.True(t, plan(&structpb.Struct{}).IsEmpty())
+	assert.True(t, plan(&structpb.Value{}).IsEmpty())
+	assert.True(t, plan(&structpb.ListValue{}).IsEmpty())
+
+	// Recursive messages with deprecated fields, directly or through extensions.
+	assert.False(t, plan(&pb.AllInclusive{}).IsEmpty())
+	assert.False(t, plan(&pb.AllInclusive_NestedRecursive{}).IsEmpty())
+	assert.False(t, plan(&pb.Extendable{}).IsEmpty())
+	assert.False(t, plan(&pb.Resource{}).IsEmpty())
+}
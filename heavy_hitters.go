@@ -0,0 +1,208 @@
+package apideprecation
+
+import (
+	"cmp"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// DefaultHeavyHittersCapacity is the default number of callers that
+// HeavyHitters keeps per deprecated element.
+const DefaultHeavyHittersCapacity = 100
+
+// HeavyHitters keeps an approximate list of the top callers of every deprecated
+// element with the space-saving algorithm: memory is bounded by the capacity
+// per element, regardless of the number of distinct callers. Use it instead of
+// a caller label to avoid unbounded metric cardinality. Attach it to Metrics
+// using WithHeavyHitters.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+type HeavyHitters struct {
+	callerKey CallerKeyFunc
+	capacity  int
+
+	elements sync.Map // Element -> *spaceSaving
+}
+
+// HeavyHittersOption configures HeavyHitters.
+type HeavyHittersOption func(*HeavyHitters)
+
+// WithHeavyHittersCapacity sets the number of callers kept per element.
+// Counts of callers ranked below the capacity are approximate. Defaults to
+// DefaultHeavyHittersCapacity.
+func WithHeavyHittersCapacity(n int) HeavyHittersOption {
+	return func(h *HeavyHitters) {
+		if n > 0 {
+			h.capacity = n
+		}
+	}
+}
+
+// NewHeavyHitters creates empty HeavyHitters that identify callers by
+// callerKey, e.g. CallerFromMetadata("x-client-name").
+func NewHeavyHitters(callerKey CallerKeyFunc, opts ...HeavyHittersOption) *HeavyHitters {
+	h := &HeavyHitters{callerKey: callerKey, capacity: DefaultHeavyHittersCapacity}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// CallerCount is the approximate usage count of a deprecated element by a
+// caller. Count may overestimate the true count by at most Error.
+type CallerCount struct {
+	Caller string `json:"caller"`
+	Count  uint64 `json:"count"`
+	Error  uint64 `json:"error"`
+}
+
+// ElementCallers is the list of top callers of a deprecated element.
+type ElementCallers struct {
+	Element Element       `json:"element"`
+	Callers []CallerCount `json:"callers"`
+}
+
+func (h *HeavyHitters) record(ctx context.Context, meta CallMeta, element Element) {
+	v, ok := h.elements.Load(element)
+	if !ok {
+		v, _ = h.elements.LoadOrStore(element, newSpaceSaving(h.capacity))
+	}
+	v.(*spaceSaving).add(h.callerKey(ctx, meta))
+}
+
+// Top returns up to n top callers of element, sorted by descending count. An
+// unknown caller is reported with an empty Caller. n <= 0 returns all kept callers.
+func (h *HeavyHitters) Top(element Element, n int) []CallerCount {
+	v, ok := h.elements.Load(element)
+	if !ok {
+		return nil
+	}
+	return v.(*spaceSaving).top(n)
+}
+
+// TopAll returns up to n top callers of every element used so far, sorted by
+// element.
+func (h *HeavyHitters) TopAll(n int) []ElementCallers {
+	var all []ElementCallers
+	h.elements.Range(func(k, v any) bool {
+		all = append(all, ElementCallers{Element: k.(Element), Callers: v.(*spaceSaving).top(n)})
+		return true
+	})
+	slices.SortFunc(all, func(a, b ElementCallers) int {
+		return compareElements(a.Element, b.Element)
+	})
+	return all
+}
+
+// Handler returns an http.Handler that serves TopAll as JSON. The "n" query
+// parameter limits the callers per element (10 by default), and the "element"
+// parameter filters by element full name.
+func (h *HeavyHitters) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := 10
+		if s := r.URL.Query().Get("n"); s != "" {
+			v, err := strconv.Atoi(s)
+			if err != nil {
+				http.Error(w, "invalid n: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			n = v
+		}
+		all := h.TopAll(n)
+		if name := r.URL.Query().Get("element"); name != "" {
+			all = slices.DeleteFunc(all, func(e ElementCallers) bool { return string(e.Element.Name) != name })
+		}
+		if all == nil {
+			all = []ElementCallers{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(struct {
+			Elements []ElementCallers `json:"elements"`
+		}{all})
+	})
+}
+
+// spaceSaving is a space-saving sketch: it keeps at most capacity counters, and
+// a new caller replaces the caller with the minimum count, inheriting it as the
+// error bound.
+type spaceSaving struct {
+	mu       sync.Mutex
+	capacity int
+	counters map[string]*ssCounter
+	heap     ssHeap // min-heap by count
+}
+
+type ssCounter struct {
+	caller string
+	count  uint64
+	error  uint64
+	index  int
+}
+
+func newSpaceSaving(capacity int) *spaceSaving {
+	return &spaceSaving{capacity: capacity, counters: make(map[string]*ssCounter)}
+}
+
+func (s *spaceSaving) add(caller string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.counters[caller]; ok {
+		c.count++
+		heap.Fix(&s.heap, c.index)
+		return
+	}
+	if len(s.heap) < s.capacity {
+		c := &ssCounter{caller: caller, count: 1}
+		s.counters[caller] = c
+		heap.Push(&s.heap, c)
+		return
+	}
+	c := s.heap[0]
+	delete(s.counters, c.caller)
+	c.caller, c.error = caller, c.count
+	c.count++
+	s.counters[caller] = c
+	heap.Fix(&s.heap, 0)
+}
+
+func (s *spaceSaving) top(n int) []CallerCount {
+	s.mu.Lock()
+	counts := make([]CallerCount, 0, len(s.heap))
+	for _, c := range s.heap {
+		counts = append(counts, CallerCount{Caller: c.caller, Count: c.count, Error: c.error})
+	}
+	s.mu.Unlock()
+	slices.SortFunc(counts, func(a, b CallerCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Caller, b.Caller))
+	})
+	if n > 0 && len(counts) > n {
+		counts = counts[:n]
+	}
+	return counts
+}
+
+type ssHeap []*ssCounter
+
+func (h ssHeap) Len() int           { return len(h) }
+func (h ssHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h ssHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+
+func (h *ssHeap) Push(x any) {
+	c := x.(*ssCounter)
+	c.index = len(*h)
+	*h = append(*h, c)
+}
+
+func (h *ssHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,68 @@
+package apideprecation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+type callerCtxKey struct{}
+
+func TestHeavyHitters(t *testing.T) {
+	hitters := NewHeavyHitters(func(ctx context.Context, _ CallMeta) string {
+		caller, _ := ctx.Value(callerCtxKey{}).(string)
+		return caller
+	}, WithHeavyHittersCapacity(2))
+	interceptor := NewMetrics(WithHeavyHitters(hitters)).UnaryServerInterceptor()
+	call := func(caller string, req any) {
+		_, err := interceptor(
+			context.WithValue(context.Background(), callerCtxKey{}, caller), req,
+			&grpc.UnaryServerInfo{FullMethod: "/t.Service/Method"},
+			func(ctx context.Context, req any) (any, error) { return nil, nil },
+		)
+		require.NoError(t, err)
+	}
+
+	for range 5 {
+		call("a", &pb.AllInclusive{ScalarDeprecated: 1})
+	}
+	for range 3 {
+		call("b", &pb.AllInclusive{ScalarDeprecated: 1})
+	}
+	call("c", &pb.AllInclusive{ScalarDeprecated: 1, Enum: pb.Enum_ENUM_DEPRECATED})
+
+	scalarDeprecated := Element{Kind: ElementField, Name: "AllInclusive.scalar_deprecated"}
+	enumDeprecated := Element{Kind: ElementEnumValue, Name: "ENUM_DEPRECATED"}
+
+	// "c" evicts "b", inheriting its count as the error bound.
+	assert.Equal(t, []CallerCount{
+		{Caller: "a", Count: 5},
+		{Caller: "c", Count: 4, Error: 3},
+	}, hitters.Top(scalarDeprecated, 0))
+	assert.Equal(t, []CallerCount{{Caller: "a", Count: 5}}, hitters.Top(scalarDeprecated, 1))
+	assert.Equal(t, []ElementCallers{
+		{Element: enumDeprecated, Callers: []CallerCount{{Caller: "c", Count: 1}}},
+		{Element: scalarDeprecated, Callers: []CallerCount{{Caller: "a", Count: 5}}},
+	}, hitters.TopAll(1))
+	assert.Nil(t, hitters.Top(Element{Kind: ElementField, Name: "Simple.field_deprecated"}, 0))
+
+	rec := httptest.NewRecorder()
+	hitters.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/?n=1&element=ENUM_DEPRECATED", nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var body struct {
+		Elements []ElementCallers `json:"elements"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, []ElementCallers{{Element: enumDeprecated, Callers: []CallerCount{{Caller: "c", Count: 1}}}}, body.Elements)
+
+	rec = httptest.NewRecorder()
+	hitters.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/?n=x", nil))
+	assert.Equal(t, 400, rec.Code)
+}
@@ -0,0 +1,313 @@
+package apideprecation
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// HTTPOption configures the middleware returned by Metrics.HTTPMiddleware.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+type HTTPOption func(*httpConfig)
+
+type httpConfig struct {
+	files       *protoregistry.Files
+	docsURL     func(desc protoreflect.Descriptor) string
+	maxBodySize int64
+}
+
+// WithHTTPFiles sets the registry whose services' google.api.http rules are
+// used for routing. Defaults to protoregistry.GlobalFiles.
+func WithHTTPFiles(files *protoregistry.Files) HTTPOption {
+	return func(c *httpConfig) {
+		c.files = files
+	}
+}
+
+// WithHTTPDocsURL sets a function returning the documentation URL of a
+// deprecated method, advertised in the Link header with rel="deprecation".
+// Defaults to the docs_url of the method's DeprecationDetails.
+func WithHTTPDocsURL(fn func(desc protoreflect.Descriptor) string) HTTPOption {
+	return func(c *httpConfig) {
+		c.docsURL = fn
+	}
+}
+
+// WithHTTPMaxBodySize limits the size of request bodies inspected for
+// deprecated fields. Larger bodies are passed through without inspection.
+// Defaults to 4 MiB.
+func WithHTTPMaxBodySize(n int64) HTTPOption {
+	return func(c *httpConfig) {
+		c.maxBodySize = n
+	}
+}
+
+// HTTPMiddleware returns an http.Handler that records deprecated API usage of
+// HTTP/JSON requests transcoded to gRPC, e.g. by grpc-gateway, before calling next.
+//
+// Requests are mapped to RPC methods using the google.api.http rules of the
+// registered services, and are recorded with CallMeta.Transport set to
+// TransportHTTP. The JSON request body is decoded into the method's input
+// message and checked for deprecated fields and enum values; path and query
+// parameters are not inspected.
+//
+// The transport is not a label by default: to keep the label sets of existing
+// series stable, HTTP and gRPC usage are counted in the same series. Add
+// TransportLabel to every LabelSet of WithExtraLabels to tell them apart:
+//
+//	apideprecation.NewMetrics(apideprecation.WithExtraLabels(apideprecation.LabelSet{
+//		Method: []apideprecation.Label{apideprecation.TransportLabel()},
+//		Field:  []apideprecation.Label{apideprecation.TransportLabel()},
+//		Enum:   []apideprecation.Label{apideprecation.TransportLabel()},
+//	}))
+//
+// Responses to deprecated methods get the RFC 9745 Deprecation header, the
+// RFC 8594 Sunset header with the effective_at date of DeprecationDetails, and
+// a Link header to the documentation (see WithHTTPDocsURL). Since descriptors
+// do not record when an element was deprecated, Deprecation is set to "@0",
+// meaning it is already deprecated.
+//
+// Requests rejected by brownouts or a policy (see WithBrownouts and
+// WithPolicy) get a plain text error with the 501 Not Implemented status for
+// methods and 400 Bad Request for fields and enum values, as grpc-gateway maps
+// the gRPC codes. Requests throttled by WithThrottling get 429 Too Many
+// Requests and a Retry-After header.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func (m *Metrics) HTTPMiddleware(next http.Handler, opts ...HTTPOption) http.Handler {
+	cfg := &httpConfig{files: protoregistry.GlobalFiles, maxBodySize: 4 << 20}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	routes := buildHTTPRoutes(cfg)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := matchHTTPRoute(routes, r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var msg proto.Message
+		if route.deprecated {
+			for _, h := range route.headers {
+				w.Header().Add(h[0], h[1])
+			}
+			msg = route.input.New().Interface()
+		} else {
+			msg = route.decodeBody(r, cfg.maxBodySize)
+		}
+		ctx := withHTTPRequestInfo(r.Context(), &httpRequestInfo{header: r.Header, peer: r.RemoteAddr, tls: r.TLS})
+		warns, err := m.observe(ctx, msg, route.meta, &callEnforcement{})
+		warns.addTo(w.Header())
+		if err != nil {
+			if throttleErr, ok := err.(*ThrottleError); ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttleErr.RetryAfter.Seconds()))))
+			}
+			http.Error(w, err.Error(), httpStatusFromCode(status.Code(err)))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type httpRoute struct {
+	httpMethod string
+	pattern    httpPattern
+	body       string // google.api.HttpRule.body
+	meta       CallMeta
+	input      protoreflect.MessageType
+	deprecated bool
+	headers    [][2]string
+}
+
+func buildHTTPRoutes(cfg *httpConfig) []*httpRoute {
+	var routes []*httpRoute
+	cfg.files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := range services.Len() {
+			methods := services.Get(i).Methods()
+			for j := range methods.Len() {
+				md := methods.Get(j)
+				rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+				if !ok || rule == nil {
+					continue
+				}
+				for _, binding := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+					if route := newHTTPRoute(cfg, md, binding); route != nil {
+						routes = append(routes, route)
+					}
+				}
+			}
+		}
+		return true
+	})
+	slices.SortStableFunc(routes, func(a, b *httpRoute) int {
+		return cmp.Or(
+			cmp.Compare(b.pattern.literals(), a.pattern.literals()),
+			cmp.Compare(len(b.pattern.segments), len(a.pattern.segments)),
+		)
+	})
+	return routes
+}
+
+func newHTTPRoute(cfg *httpConfig, md protoreflect.MethodDescriptor, rule *annotations.HttpRule) *httpRoute {
+	var httpMethod, template string
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		httpMethod, template = http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		httpMethod, template = http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		httpMethod, template = http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		httpMethod, template = http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		httpMethod, template = http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		httpMethod, template = p.Custom.GetKind(), p.Custom.GetPath()
+	default:
+		return nil
+	}
+	pattern, err := parseHTTPPattern(template)
+	if err != nil {
+		return nil
+	}
+
+	input, err := protoregistry.GlobalTypes.FindMessageByName(md.Input().FullName())
+	if err != nil {
+		input = dynamicpb.NewMessageType(md.Input())
+	}
+
+	service, method := string(md.Parent().FullName()), string(md.Name())
+	route := &httpRoute{
+		httpMethod: httpMethod,
+		pattern:    pattern,
+		body:       rule.GetBody(),
+		meta: CallMeta{
+			FullMethod: "/" + service + "/" + method,
+			Type:       string(callTypeOf(md)),
+			Service:    service,
+			Method:     method,
+			Transport:  TransportHTTP,
+			Side:       SideServer,
+		},
+		input:      input,
+		deprecated: isMethodOrServiceDeprecated(md),
+	}
+	if route.deprecated {
+		route.headers = deprecationHeaders(cfg, md)
+	}
+	return route
+}
+
+func deprecationHeaders(cfg *httpConfig, md protoreflect.MethodDescriptor) [][2]string {
+	headers := [][2]string{{"Deprecation", "@0"}}
+	if sunset, err := time.Parse(time.DateOnly, DeprecationDetails(md).GetEffectiveAt()); err == nil {
+		headers = append(headers, [2]string{"Sunset", sunset.UTC().Format(http.TimeFormat)})
+	}
+	url := DeprecationDetails(md).GetDocsUrl()
+	if cfg.docsURL != nil {
+		url = cfg.docsURL(md)
+	}
+	if url != "" {
+		headers = append(headers, [2]string{"Link", fmt.Sprintf(`<%s>; rel="deprecation"; type="text/html"`, url)})
+	}
+	return headers
+}
+
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func callTypeOf(md protoreflect.MethodDescriptor) interceptors.GRPCType {
+	switch {
+	case md.IsStreamingClient() && md.IsStreamingServer():
+		return interceptors.BidiStream
+	case md.IsStreamingClient():
+		return interceptors.ClientStream
+	case md.IsStreamingServer():
+		return interceptors.ServerStream
+	default:
+		return interceptors.Unary
+	}
+}
+
+func matchHTTPRoute(routes []*httpRoute, r *http.Request) *httpRoute {
+	for _, route := range routes {
+		if route.httpMethod == r.Method && route.pattern.Match(r.URL.Path) {
+			return route
+		}
+	}
+	return nil
+}
+
+// decodeBody decodes the JSON request body into the route's input message.
+// The body is restored for the next handler, along with any error reading it.
+// Bodies that cannot be read or decoded yield a partially populated or empty
+// message.
+func (route *httpRoute) decodeBody(r *http.Request, maxBodySize int64) proto.Message {
+	msg := route.input.New().Interface()
+	if route.body == "" || r.Body == nil || r.Body == http.NoBody {
+		return msg
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if int64(len(body)) > maxBodySize {
+		r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+		return msg
+	}
+	if err != nil {
+		r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), errReader{err}), Closer: r.Body}
+		return msg
+	}
+	r.Body = readCloser{Reader: bytes.NewReader(body), Closer: r.Body}
+	if len(body) == 0 {
+		return msg
+	}
+
+	if route.body != "*" {
+		// Nest the body under its field, e.g. {"resource": <body>}, so that any
+		// field kind is decoded by protojson.
+		fd := route.input.Descriptor().Fields().ByName(protoreflect.Name(route.body))
+		if fd == nil {
+			return msg
+		}
+		body = slices.Concat([]byte(`{"`), []byte(fd.JSONName()), []byte(`":`), body, []byte(`}`))
+	}
+	_ = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(body, msg)
+	return msg
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// errReader fails every read with err.
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
@@ -0,0 +1,92 @@
+package apideprecation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// httpPattern is a compiled google.api.http path template, e.g.
+// "/v1/{name=projects/*/books/*}:publish". Variables are matched by their
+// segments and are not captured.
+type httpPattern struct {
+	segments []string // literal, "*" (one segment) or "**" (the rest of the path)
+	verb     string
+}
+
+func parseHTTPPattern(template string) (httpPattern, error) {
+	if !strings.HasPrefix(template, "/") {
+		return httpPattern{}, fmt.Errorf("http template %q: must start with '/'", template)
+	}
+	rest := template[1:]
+
+	var p httpPattern
+	if i := strings.LastIndexByte(rest, ':'); i >= 0 && !strings.ContainsAny(rest[i:], "/}") {
+		rest, p.verb = rest[:i], rest[i+1:]
+	}
+
+	for rest != "" {
+		var segment string
+		if rest[0] == '{' {
+			end := strings.IndexByte(rest, '}')
+			if end < 0 {
+				return httpPattern{}, fmt.Errorf("http template %q: unterminated variable", template)
+			}
+			variable := rest[1:end]
+			rest = strings.TrimPrefix(rest[end+1:], "/")
+			if _, segments, ok := strings.Cut(variable, "="); ok {
+				p.segments = append(p.segments, strings.Split(segments, "/")...)
+			} else {
+				p.segments = append(p.segments, "*")
+			}
+			continue
+		}
+		segment, rest, _ = strings.Cut(rest, "/")
+		p.segments = append(p.segments, segment)
+	}
+
+	for i, segment := range p.segments {
+		if segment == "" {
+			return httpPattern{}, fmt.Errorf("http template %q: empty segment", template)
+		}
+		if segment == "**" && i != len(p.segments)-1 {
+			return httpPattern{}, fmt.Errorf("http template %q: '**' must be the last segment", template)
+		}
+	}
+	return p, nil
+}
+
+// literals is the number of literal segments, used to prefer more specific patterns.
+func (p httpPattern) literals() int {
+	n := 0
+	for _, segment := range p.segments {
+		if segment != "*" && segment != "**" {
+			n++
+		}
+	}
+	return n
+}
+
+func (p httpPattern) Match(path string) bool {
+	path = strings.TrimPrefix(path, "/")
+	if p.verb != "" {
+		var ok bool
+		if path, ok = strings.CutSuffix(path, ":"+p.verb); !ok {
+			return false
+		}
+	}
+
+	for _, segment := range p.segments {
+		if segment == "**" {
+			return true
+		}
+		if path == "" {
+			return false
+		}
+		var part string
+		part, path, _ = strings.Cut(path, "/")
+		if segment != "*" && segment != part {
+			return false
+		}
+	}
+	return path == ""
+}
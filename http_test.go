@@ -0,0 +1,118 @@
+package apideprecation
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	metrics := NewMetrics(WithExtraLabels(LabelSet{
+		Method: []Label{TransportLabel()},
+		Field:  []Label{TransportLabel()},
+		Enum:   []Label{TransportLabel()},
+	}))
+
+	var gotBody string
+	handler := metrics.HTTPMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			gotBody = string(body)
+		}),
+		WithHTTPDocsURL(func(desc protoreflect.Descriptor) string {
+			return "https://example.com/docs/" + string(desc.Name())
+		}),
+	)
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	t.Run("deprecated method", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/v1/legacy/resources/1", "")
+		assert.Equal(t, "@0", rec.Header().Get("Deprecation"))
+		assert.Equal(t, "Sun, 01 Jun 2025 00:00:00 GMT", rec.Header().Get("Sunset"))
+		assert.Equal(t, `<https://example.com/docs/GetResourceLegacy>; rel="deprecation"; type="text/html"`, rec.Header().Get("Link"))
+
+		c := metrics.deprecatedMethodUsed.WithLabelValues("unary", "testdata.ResourceService", "GetResourceLegacy", "http")
+		assert.Equal(t, float64(1), testutil.ToFloat64(c))
+	})
+
+	t.Run("not deprecated method", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/v1/resources/1", "")
+		assert.Empty(t, rec.Header().Get("Deprecation"))
+		assert.Empty(t, rec.Header().Get("Sunset"))
+	})
+
+	t.Run("deprecated fields in body", func(t *testing.T) {
+		body := `{"name": "resources/1", "title": "t", "state": "STATE_LEGACY", "unknown": 1}`
+		rec := serve(http.MethodPatch, "/v1/resources/1", body)
+		assert.Empty(t, rec.Header().Get("Deprecation"))
+		assert.Equal(t, body, gotBody, "body is passed to the next handler")
+
+		c := metrics.deprecatedFieldUsed.WithLabelValues("unary", "testdata.ResourceService", "UpdateResource", "resource.title", "implicit", "http")
+		assert.Equal(t, float64(1), testutil.ToFloat64(c))
+		c = metrics.deprecatedEnumUsed.WithLabelValues("unary", "testdata.ResourceService", "UpdateResource", "resource.state", "STATE_LEGACY", "2", "http")
+		assert.Equal(t, float64(1), testutil.ToFloat64(c))
+	})
+
+	t.Run("unknown route", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/v2/resources/1", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+func TestHTTPMiddleware_bodyReadError(t *testing.T) {
+	errRead := errors.New("connection reset")
+	var gotBody string
+	var gotErr error
+	handler := NewMetrics().HTTPMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		gotBody, gotErr = string(body), err
+	}))
+
+	body := io.MultiReader(strings.NewReader(`{"name": "resources/1"`), iotest.ErrReader(errRead))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPatch, "/v1/resources/1", body))
+	assert.Equal(t, `{"name": "resources/1"`, gotBody)
+	assert.ErrorIs(t, gotErr, errRead, "read error is passed to the next handler")
+}
+
+func TestHTTPPattern(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+		want     bool
+	}{
+		{template: "/v1/{name=resources/*}", path: "/v1/resources/1", want: true},
+		{template: "/v1/{name=resources/*}", path: "/v1/resources/1/children", want: false},
+		{template: "/v1/{name=resources/*}", path: "/v1/resources", want: false},
+		{template: "/v1/{name}", path: "/v1/a", want: true},
+		{template: "/v1/{name=files/**}", path: "/v1/files/a/b/c", want: true},
+		{template: "/v1/{name=resources/*}:publish", path: "/v1/resources/1:publish", want: true},
+		{template: "/v1/{name=resources/*}:publish", path: "/v1/resources/1", want: false},
+		{template: "/v1/resources", path: "/v1/resources", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template+" "+tt.path, func(t *testing.T) {
+			p, err := parseHTTPPattern(tt.template)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, p.Match(tt.path))
+		})
+	}
+
+	_, err := parseHTTPPattern("/v1/{name")
+	assert.Error(t, err)
+	_, err = parseHTTPPattern("/v1/**/a")
+	assert.Error(t, err)
+}
@@ -0,0 +1,139 @@
+package apideprecation
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	deprecation "github.com/belo4ya/grpc-api-deprecation/annotations"
+)
+
+// Usage is a use of a deprecated element found by an Inspector.
+type Usage struct {
+	// Element is the kind and full name of the deprecated element.
+	Element
+	// Descriptor is the deprecated method, field, or enum value, or the
+	// deprecated message type of a field referenced by a FieldMask.
+	Descriptor protoreflect.Descriptor
+	// Field is the field that holds the deprecated field or enum value. It is
+	// nil for methods.
+	Field protoreflect.FieldDescriptor
+	// Path is the field path of fields and enum values, e.g. "items[].state",
+	// rendered as configured by WithFieldPathStyle and WithFieldPathKeys.
+	Path string
+	// Presence is the presence of fields: "explicit" or "implicit".
+	Presence string
+	// EnumValue is the deprecated enum value of enum values.
+	EnumValue protoreflect.EnumValueDescriptor
+	// Via tells how fields and enum values are used: "value", "field_mask"
+	// (see WithFieldMasks), or "implicit_default" (see WithImplicitEnumDefaults).
+	Via string
+	// Details is the DeprecationDetails of Descriptor, or nil.
+	Details *deprecation.DeprecationDetails
+}
+
+// Warning returns the warning that WithWarnings sends for the usage.
+func (u Usage) Warning() string {
+	switch u.Kind {
+	case ElementMethod:
+		return methodWarning(u.Descriptor.(protoreflect.MethodDescriptor))
+	case ElementEnumValue:
+		return enumValueWarning(u.EnumValue, u.Path)
+	default:
+		return fieldWarning(u.Field, u.Path)
+	}
+}
+
+// Inspector finds uses of deprecated fields and enum values in messages, and of
+// deprecated methods, with the same cached evaluation plans as the interceptors
+// of Metrics. Unlike Metrics, it is not bound to a transport, e.g. to inspect
+// messages consumed from Kafka or read from storage. It is safe for concurrent use.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+type Inspector struct {
+	cfg       *config
+	reporters *reporters
+}
+
+// NewInspector creates an Inspector. It accepts the Options of NewMetrics that
+// affect which usages are found and how they are reported: WithFiles,
+// WithExtensionTypes, WithPrewarm, WithFieldMasks, WithFieldPathStyle,
+// WithFieldPathKeys, and WithImplicitEnumDefaults. Other options are ignored.
+func NewInspector(opts ...Option) *Inspector {
+	cfg := &config{files: protoregistry.GlobalFiles, extTypes: protoregistry.GlobalTypes}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	svcSeed, msgSeed := resolvePrewarm(cfg.files, cfg.seedDesc)
+	return &Inspector{cfg: cfg, reporters: newReporters(cfg, cfg.files, cfg.extTypes, svcSeed, msgSeed)}
+}
+
+var defaultInspector = NewInspector()
+
+// Inspect returns the uses of deprecated fields and enum values in msg, see
+// Inspector.Inspect, with plans cached by a shared Inspector with the default
+// options. To use options, create an Inspector with NewInspector and reuse it.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func Inspect(msg proto.Message) []Usage {
+	return defaultInspector.Inspect(msg)
+}
+
+// Inspect returns the uses of deprecated fields and enum values in msg and its
+// nested messages, in the order they are found.
+func (i *Inspector) Inspect(msg proto.Message) []Usage {
+	if msg == nil || !msg.ProtoReflect().IsValid() {
+		return nil
+	}
+	return i.inspect(CallMeta{}, msg, nil)
+}
+
+// InspectCall returns the uses of deprecated elements in a call of fullMethod,
+// e.g. "/pkg.Service/Method", with the request req: the method, if it is
+// deprecated, followed by the usages in req and, if WithFieldMasks is enabled,
+// in the paths of its FieldMasks. req may be nil to inspect only the method.
+func (i *Inspector) InspectCall(fullMethod string, req proto.Message) []Usage {
+	var usages []Usage
+	i.reporters.method.Report(fullMethod, func(md protoreflect.MethodDescriptor) {
+		usages = append(usages, Usage{
+			Element:    methodElement(md),
+			Descriptor: md,
+			Details:    DeprecationDetails(md),
+		})
+	})
+	if req == nil || !req.ProtoReflect().IsValid() {
+		return usages
+	}
+	return i.inspect(newCallMeta(fullMethod, nil), req, usages)
+}
+
+func (i *Inspector) inspect(meta CallMeta, msg proto.Message, usages []Usage) []Usage {
+	onDeprecatedField := func(via string) onDeprecatedFieldFunc {
+		return func(fd protoreflect.FieldDescriptor, fieldFullName, fieldPresence string) {
+			desc := deprecatedFieldDescriptor(fd)
+			usages = append(usages, Usage{
+				Element:    deprecatedFieldElement(fd),
+				Descriptor: desc,
+				Field:      fd,
+				Path:       fieldFullName,
+				Presence:   fieldPresence,
+				Via:        via,
+				Details:    DeprecationDetails(desc),
+			})
+		}
+	}
+	if i.cfg.fieldMasks && meta.FullMethod != "" {
+		i.reporters.fieldMask.Report(msg.ProtoReflect(), meta, onDeprecatedField(viaFieldMask))
+	}
+	i.reporters.field.Report(msg.ProtoReflect(), meta, onDeprecatedField(viaValue),
+		func(fd protoreflect.FieldDescriptor, evd protoreflect.EnumValueDescriptor, fieldFullName, via string) {
+			usages = append(usages, Usage{
+				Element:    enumValueElement(evd),
+				Descriptor: evd,
+				Field:      fd,
+				Path:       fieldFullName,
+				EnumValue:  evd,
+				Via:        via,
+				Details:    DeprecationDetails(evd),
+			})
+		})
+	return usages
+}
@@ -0,0 +1,66 @@
+package apideprecation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestInspect(t *testing.T) {
+	usages := Inspect(&pb.Resource{
+		Title:    "t",
+		Children: []*pb.Resource{{State: pb.State_STATE_LEGACY}},
+	})
+	require.Len(t, usages, 2)
+
+	fields := (&pb.Resource{}).ProtoReflect().Descriptor().Fields()
+	enumValue := pb.State_STATE_LEGACY.Descriptor().Values().ByNumber(protoreflect.EnumNumber(pb.State_STATE_LEGACY))
+	assert.Equal(t, Usage{
+		Element:    Element{Kind: ElementEnumValue, Name: "testdata.STATE_LEGACY"},
+		Descriptor: enumValue,
+		Field:      fields.ByName("state"),
+		Path:       "children[].state",
+		EnumValue:  enumValue,
+		Via:        viaValue,
+		Details:    DeprecationDetails(enumValue),
+	}, usages[0])
+	assert.Equal(t, Usage{
+		Element:    Element{Kind: ElementField, Name: "testdata.Resource.title"},
+		Descriptor: fields.ByName("title"),
+		Field:      fields.ByName("title"),
+		Path:       "title",
+		Presence:   "implicit",
+		Via:        viaValue,
+		Details:    DeprecationDetails(fields.ByName("title")),
+	}, usages[1])
+	assert.Equal(t, "2025-03-01", usages[1].Details.GetEffectiveAt())
+	assert.Equal(t, "field title (testdata.Resource.title) is deprecated and will stop working on 2025-03-01: Use display_name instead.", usages[1].Warning())
+
+	usages = NewInspector(WithFieldPathStyle(FieldPathNumbers)).Inspect(&pb.Resource{Title: "t"})
+	require.Len(t, usages, 1)
+	assert.Equal(t, "101", usages[0].Path)
+
+	assert.Empty(t, Inspect(&pb.Resource{DisplayName: "t"}))
+	assert.Empty(t, Inspect((*pb.Resource)(nil)))
+}
+
+func TestInspector_InspectCall(t *testing.T) {
+	inspector := NewInspector(WithFieldMasks())
+
+	usages := inspector.InspectCall("/testdata.ResourceService/GetResourceLegacy", &pb.GetResourceRequest{
+		ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+	})
+	require.Len(t, usages, 2)
+	assert.Equal(t, Element{Kind: ElementMethod, Name: "testdata.ResourceService.GetResourceLegacy"}, usages[0].Element)
+	assert.Equal(t, "method testdata.ResourceService.GetResourceLegacy is deprecated and will stop working on 2025-06-01: Use GetResource instead.", usages[0].Warning())
+	assert.Equal(t, Element{Kind: ElementField, Name: "testdata.Resource.title"}, usages[1].Element)
+	assert.Equal(t, viaFieldMask, usages[1].Via)
+
+	usages = inspector.InspectCall("/testdata.ResourceService/GetResource", nil)
+	assert.Empty(t, usages)
+}
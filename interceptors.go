@@ -2,71 +2,141 @@ package apideprecation
 
 import (
 	"context"
+	"slices"
 	"strconv"
+	"sync/atomic"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
 )
 
+// Names of the counters exposed by Metrics, before WithNamespace and
+// WithSubsystem are applied.
+const (
+	MethodUsedMetricName = "grpc_deprecated_method_used_total"
+	FieldUsedMetricName  = "grpc_deprecated_field_used_total"
+	EnumUsedMetricName   = "grpc_deprecated_enum_used_total"
+
+	// BrownoutRejectedMetricName is the counter of calls rejected by
+	// brownouts, exposed if WithBrownouts or WithPolicy is enabled.
+	BrownoutRejectedMetricName = "grpc_deprecated_brownout_rejected_total"
+	// ThrottleDecisionsMetricName is the counter of throttle decisions,
+	// exposed if WithThrottling is enabled.
+	ThrottleDecisionsMetricName = "grpc_deprecated_throttle_decisions_total"
+	// FieldMigratedMetricName is the counter of deprecated fields migrated
+	// into their replacements, exposed if WithRequestMigration is enabled.
+	FieldMigratedMetricName = "grpc_deprecated_field_migrated_total"
+)
+
 // Metrics exposes Prometheus counters that track deprecated gRPC API usage.
 // It also provides the server interceptors that update those counters.
 type Metrics struct {
 	cfg         *config
 	extraLabels compiledLabels
 	exemplar    compiledLabels
+	// hasLabelFuncs is set if there are extra labels or exemplars, whose
+	// LabelValueFuncs get the deprecated descriptor in the context.
+	hasLabelFuncs bool
 
-	methodReporter *methodReporter
-	fieldReporter  *fieldReporter
+	reporters atomic.Pointer[reporters] // swapped by Reload
 
 	deprecatedMethodUsed *prometheus.CounterVec
 	deprecatedFieldUsed  *prometheus.CounterVec
 	deprecatedEnumUsed   *prometheus.CounterVec
+	brownoutRejected     *prometheus.CounterVec   // nil unless WithBrownouts or WithPolicy
+	throttleDecisions    *prometheus.CounterVec   // nil unless WithThrottling
+	fieldMigrated        *prometheus.CounterVec   // nil unless WithRequestMigration
+	occurrences          *prometheus.HistogramVec // nil unless WithOccurrencesHistogram
 }
 
 // NewMetrics builds a Metrics collector with unary and stream interceptors.
 // NOTE: Remember to register Metrics object by using prometheus registry, e.g. prometheus.MustRegister(metrics).
 func NewMetrics(opts ...Option) *Metrics {
-	cfg := &config{}
+	cfg := &config{files: protoregistry.GlobalFiles, extTypes: protoregistry.GlobalTypes}
 	for _, opt := range opts {
 		opt(cfg)
 	}
 
-	svcSeed, msgSeed := resolvePrewarm(cfg.seedDesc)
-	methodReporter := newMethodReporter(svcSeed)
-	fieldReporter := newFieldReporter(msgSeed)
+	svcSeed, msgSeed := resolvePrewarm(cfg.files, cfg.seedDesc)
 
 	defaultLabels := []string{"grpc_type", "grpc_service", "grpc_method"}
 
 	extraLabels := cfg.extraLabels.compile()
-	methodLabels := append(defaultLabels, extraLabels.methodLabels...)
-	fieldLabels := append(append(defaultLabels, "field", "field_presence"), extraLabels.fieldLabels...)
-	enumLabels := append(append(defaultLabels, "field", "enum_value", "enum_number"), extraLabels.enumLabels...)
-
-	return &Metrics{
-		cfg:            cfg,
-		extraLabels:    extraLabels,
-		exemplar:       cfg.exemplar.compile(),
-		methodReporter: methodReporter,
-		fieldReporter:  fieldReporter,
+	methodLabels := slices.Clone(defaultLabels)
+	fieldLabels := append(slices.Clone(defaultLabels), "field", "field_presence")
+	if cfg.fieldMasks {
+		fieldLabels = append(fieldLabels, "via")
+	}
+	enumLabels := append(slices.Clone(defaultLabels), "field", "enum_value", "enum_number")
+	if cfg.enumDefault != nil {
+		enumLabels = append(enumLabels, "via")
+	}
+	if cfg.exemptions != nil {
+		methodLabels = append(methodLabels, "exempt")
+		fieldLabels = append(fieldLabels, "exempt")
+		enumLabels = append(enumLabels, "exempt")
+	}
+	if cfg.policy != nil {
+		methodLabels = append(methodLabels, "stage")
+		fieldLabels = append(fieldLabels, "stage")
+		enumLabels = append(enumLabels, "stage")
+	}
+	methodLabels = append(methodLabels, extraLabels.methodLabels...)
+	fieldLabels = append(fieldLabels, extraLabels.fieldLabels...)
+	enumLabels = append(enumLabels, extraLabels.enumLabels...)
+
+	m := &Metrics{
+		cfg:         cfg,
+		extraLabels: extraLabels,
+		exemplar:    cfg.exemplar.compile(),
+		hasLabelFuncs: len(cfg.extraLabels.Method)+len(cfg.extraLabels.Field)+len(cfg.extraLabels.Enum)+
+			len(cfg.exemplar.Method)+len(cfg.exemplar.Field)+len(cfg.exemplar.Enum) != 0,
 		deprecatedMethodUsed: prometheus.NewCounterVec(
 			cfg.counterOpts.apply(prometheus.CounterOpts{
-				Name: "grpc_deprecated_method_used_total",
+				Name: MethodUsedMetricName,
 				Help: "Count of calls to deprecated RPC methods (proto method option deprecated=true).",
 			}), methodLabels),
 		deprecatedFieldUsed: prometheus.NewCounterVec(
 			cfg.counterOpts.apply(prometheus.CounterOpts{
-				Name: "grpc_deprecated_field_used_total",
+				Name: FieldUsedMetricName,
 				Help: "Count of requests using deprecated fields (proto field option deprecated=true).",
 			}), fieldLabels),
 		deprecatedEnumUsed: prometheus.NewCounterVec(
 			cfg.counterOpts.apply(prometheus.CounterOpts{
-				Name: "grpc_deprecated_enum_used_total",
+				Name: EnumUsedMetricName,
 				Help: "Count of requests using deprecated enum values (proto enum value option deprecated=true).",
 			}), enumLabels),
 	}
+	if cfg.brownouts != nil || cfg.policy != nil {
+		m.brownoutRejected = prometheus.NewCounterVec(
+			cfg.counterOpts.apply(prometheus.CounterOpts{
+				Name: BrownoutRejectedMetricName,
+				Help: "Count of calls rejected by scheduled brownouts of deprecated elements.",
+			}), append(defaultLabels, "kind", "element"))
+	}
+	if cfg.throttler != nil {
+		m.throttleDecisions = prometheus.NewCounterVec(
+			cfg.counterOpts.apply(prometheus.CounterOpts{
+				Name: ThrottleDecisionsMetricName,
+				Help: "Count of throttle decisions on calls using deprecated elements.",
+			}), append(defaultLabels, "decision"))
+	}
+	if cfg.migration != nil {
+		m.fieldMigrated = prometheus.NewCounterVec(
+			cfg.counterOpts.apply(prometheus.CounterOpts{
+				Name: FieldMigratedMetricName,
+				Help: "Count of deprecated request fields migrated into their replacement fields.",
+			}), append(defaultLabels, "field", "replacement"))
+	}
+	if cfg.counting.histogram {
+		m.occurrences = newOccurrencesHistogram(cfg, append(defaultLabels, "kind", "element", "field", "via"))
+	}
+	m.reporters.Store(newReporters(cfg, cfg.files, cfg.extTypes, svcSeed, msgSeed))
+	return m
 }
 
 // Describe implements prometheus.Collector.
@@ -74,6 +144,18 @@ func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
 	m.deprecatedMethodUsed.Describe(ch)
 	m.deprecatedFieldUsed.Describe(ch)
 	m.deprecatedEnumUsed.Describe(ch)
+	if m.brownoutRejected != nil {
+		m.brownoutRejected.Describe(ch)
+	}
+	if m.throttleDecisions != nil {
+		m.throttleDecisions.Describe(ch)
+	}
+	if m.fieldMigrated != nil {
+		m.fieldMigrated.Describe(ch)
+	}
+	if m.occurrences != nil {
+		m.occurrences.Describe(ch)
+	}
 }
 
 // Collect implements prometheus.Collector.
@@ -81,6 +163,18 @@ func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
 	m.deprecatedMethodUsed.Collect(ch)
 	m.deprecatedFieldUsed.Collect(ch)
 	m.deprecatedEnumUsed.Collect(ch)
+	if m.brownoutRejected != nil {
+		m.brownoutRejected.Collect(ch)
+	}
+	if m.throttleDecisions != nil {
+		m.throttleDecisions.Collect(ch)
+	}
+	if m.fieldMigrated != nil {
+		m.fieldMigrated.Collect(ch)
+	}
+	if m.occurrences != nil {
+		m.occurrences.Collect(ch)
+	}
 }
 
 // UnaryServerInterceptor returns a server interceptor that records deprecated
@@ -88,7 +182,15 @@ func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
 func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
 		if msg, ok := req.(proto.Message); ok {
-			m.observe(ctx, msg, newCallMeta(info.FullMethod, nil))
+			meta := newCallMeta(info.FullMethod, nil)
+			warns, err := m.observe(ctx, msg, meta, &callEnforcement{})
+			if len(warns) != 0 {
+				_ = grpc.SetHeader(ctx, metadata.Pairs(warns.pairs()...))
+			}
+			if err != nil {
+				return nil, err
+			}
+			m.migrate(msg, meta)
 		}
 		return handler(ctx, req)
 	}
@@ -106,6 +208,8 @@ type wrappedServerStream struct {
 	grpc.ServerStream
 	metrics *Metrics
 	meta    CallMeta
+	sent    warnings // warnings already sent for previous messages
+	enforce callEnforcement
 }
 
 func (s *wrappedServerStream) RecvMsg(m any) error {
@@ -113,38 +217,227 @@ func (s *wrappedServerStream) RecvMsg(m any) error {
 		return err
 	}
 	if msg, ok := m.(proto.Message); ok {
-		s.metrics.observe(s.Context(), msg, s.meta)
+		warns, err := s.metrics.observe(s.Context(), msg, s.meta, &s.enforce)
+		if warns = s.sent.filter(warns); len(warns) != 0 {
+			s.sent = append(s.sent, warns...)
+			md := metadata.Pairs(warns.pairs()...)
+			if err := s.SetHeader(md); err != nil { // headers have already been sent
+				s.SetTrailer(md)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		s.metrics.migrate(msg, s.meta)
 	}
 	return nil
 }
 
-func (m *Metrics) observe(ctx context.Context, req proto.Message, meta CallMeta) {
+// observe records deprecated usage of the request. It returns deprecation
+// warnings for the client if WithWarnings is enabled, and a *BrownoutError,
+// *PolicyError, or *ThrottleError if the request is rejected, see
+// WithBrownouts, WithPolicy, and WithThrottling. If enforce is nil, e.g. on
+// the client side, the usage is only recorded: the request is never rejected
+// or throttled, and no rejections or throttle decisions are counted.
+func (m *Metrics) observe(ctx context.Context, req proto.Message, meta CallMeta, enforce *callEnforcement) (warnings, error) {
 	typ, service, method := meta.Type, meta.Service, meta.Method
+	reporters := m.reporters.Load()
+	var warns warnings
+	var rejectErr error
+	var used bool // non-exempted deprecated usage, subject to throttling
+	exemptions := callExemptions{cfg: m.cfg.exemptions, ctx: ctx, meta: meta}
+	counts := newRequestCounts(&m.cfg.counting)
 
 	// TODO: sync.Pool can slightly speed up the onDeprecated functions.
 
-	if m.methodReporter.Report(meta.FullMethod, func(md protoreflect.MethodDescriptor) {
-		base := []string{typ, service, method}
-		lvs := m.buildLabelValues(base, m.extraLabels.methodValues, ctx, req, meta, md, nil)
-		exemplar := m.buildExemplar(m.exemplar.methodLabels, m.exemplar.methodValues, ctx, req, meta, md, nil)
+	if reporters.method.Report(meta.FullMethod, func(md protoreflect.MethodDescriptor) {
+		m.track(ctx, meta, methodElement(md))
+		a := m.act(reporters, &exemptions, md)
+		used = used || !a.exempt
+		if a.warn {
+			warns.add(methodWarning(md))
+		}
+		if a.reject != "" && enforce != nil && rejectErr == nil {
+			rejectErr = m.reject(meta, md, methodElement(md), methodWarning(md), a.reject)
+		}
+		base := m.appendActionLabels([]string{typ, service, method}, a)
+		lctx := m.labelContext(ctx, reporters, md)
+		lvs := m.buildLabelValues(base, m.extraLabels.methodValues, lctx, req, meta, md, nil)
+		exemplar := m.buildExemplar(m.exemplar.methodLabels, m.exemplar.methodValues, lctx, req, meta, md, nil)
 		m.increment(m.deprecatedMethodUsed, lvs, exemplar)
 	}) {
-		return
+		return warns, m.throttle(ctx, meta, enforce, used, rejectErr)
 	}
 
-	m.fieldReporter.Report(req.ProtoReflect(), meta,
-		func(fd protoreflect.FieldDescriptor, fieldFullName, fieldPresence string) {
+	onDeprecatedField := func(via string) onDeprecatedFieldFunc {
+		return func(fd protoreflect.FieldDescriptor, fieldFullName, fieldPresence string) {
+			desc, element := deprecatedFieldDescriptor(fd), deprecatedFieldElement(fd)
+			handle, count := counts.add(element, fieldFullName, via)
+			if !handle {
+				return
+			}
+			a := m.act(reporters, &exemptions, desc)
+			used = used || !a.exempt
+			if a.warn {
+				warns.add(fieldWarning(fd, fieldFullName))
+			}
+			if a.reject != "" && enforce != nil && rejectErr == nil {
+				rejectErr = m.reject(meta, desc, element, fieldWarning(fd, fieldFullName), a.reject)
+			}
+			if !count {
+				return
+			}
+			m.track(ctx, meta, element)
 			base := []string{typ, service, method, fieldFullName, fieldPresence}
-			lvs := m.buildLabelValues(base, m.extraLabels.fieldValues, ctx, req, meta, nil, fd)
-			exemplar := m.buildExemplar(m.exemplar.fieldLabels, m.exemplar.fieldValues, ctx, req, meta, nil, fd)
+			if m.cfg.fieldMasks {
+				base = append(base, via)
+			}
+			base = m.appendActionLabels(base, a)
+			lctx := m.labelContext(ctx, reporters, desc)
+			lvs := m.buildLabelValues(base, m.extraLabels.fieldValues, lctx, req, meta, nil, fd)
+			exemplar := m.buildExemplar(m.exemplar.fieldLabels, m.exemplar.fieldValues, lctx, req, meta, nil, fd)
 			m.increment(m.deprecatedFieldUsed, lvs, exemplar)
-		},
-		func(fd protoreflect.FieldDescriptor, fieldFullName, enumValue string, enumNumber int) {
-			base := []string{typ, service, method, fieldFullName, enumValue, strconv.Itoa(enumNumber)}
-			lvs := m.buildLabelValues(base, m.extraLabels.enumValues, ctx, req, meta, nil, fd)
-			exemplar := m.buildExemplar(m.exemplar.enumLabels, m.exemplar.enumValues, ctx, req, meta, nil, fd)
+		}
+	}
+
+	if m.cfg.fieldMasks {
+		reporters.fieldMask.Report(req.ProtoReflect(), meta, onDeprecatedField(viaFieldMask))
+	}
+	reporters.field.Report(req.ProtoReflect(), meta, onDeprecatedField(viaValue),
+		func(fd protoreflect.FieldDescriptor, evd protoreflect.EnumValueDescriptor, fieldFullName, via string) {
+			handle, count := counts.add(enumValueElement(evd), fieldFullName, via)
+			if !handle {
+				return
+			}
+			a := m.act(reporters, &exemptions, evd)
+			used = used || !a.exempt
+			if a.warn {
+				warns.add(enumValueWarning(evd, fieldFullName))
+			}
+			if a.reject != "" && enforce != nil && rejectErr == nil {
+				rejectErr = m.reject(meta, evd, enumValueElement(evd), enumValueWarning(evd, fieldFullName), a.reject)
+			}
+			if !count {
+				return
+			}
+			m.track(ctx, meta, enumValueElement(evd))
+			base := []string{typ, service, method, fieldFullName, string(evd.Name()), strconv.Itoa(int(evd.Number()))}
+			if m.cfg.enumDefault != nil {
+				base = append(base, via)
+			}
+			base = m.appendActionLabels(base, a)
+			lctx := m.labelContext(ctx, reporters, evd)
+			lvs := m.buildLabelValues(base, m.extraLabels.enumValues, lctx, req, meta, nil, fd)
+			exemplar := m.buildExemplar(m.exemplar.enumLabels, m.exemplar.enumValues, lctx, req, meta, nil, fd)
 			m.increment(m.deprecatedEnumUsed, lvs, exemplar)
 		})
+	m.observeOccurrences(meta, &counts)
+	return warns, m.throttle(ctx, meta, enforce, used, rejectErr)
+}
+
+// callEnforcement is the state of a call whose deprecated usage is enforced,
+// shared by the messages of a stream.
+type callEnforcement struct {
+	throttled bool // the throttle decision has been made for the call
+}
+
+// action is what is done about a single use of a deprecated element.
+type action struct {
+	exempt bool
+	stage  Stage
+	warn   bool
+	reject Stage // the stage rejecting the call, StageBrownout for WithBrownouts
+}
+
+// act decides the action for a use of the deprecated element described by
+// desc, from its exemptions, policy stage, and the options of m.
+func (m *Metrics) act(reporters *reporters, exemptions *callExemptions, desc protoreflect.Descriptor) action {
+	a := action{exempt: exemptions.exempt(desc), stage: StageNone}
+	if m.cfg.policy != nil {
+		if rule := m.cfg.policy.rule(desc); rule != nil {
+			a.stage = rule.stage
+			if !a.exempt {
+				a.warn = rule.stage != StageObserve
+				if m.cfg.policy.rejects(rule) {
+					a.reject = rule.stage
+				}
+			}
+			return a
+		}
+	}
+	if !a.exempt {
+		a.warn = m.cfg.warnings
+		if m.cfg.brownouts != nil && m.cfg.brownouts.rejects(&reporters.brownouts, desc) {
+			a.reject = StageBrownout
+		}
+	}
+	return a
+}
+
+// throttle applies WithThrottling to a call with non-exempted deprecated
+// usage, unless it is not enforced, is already rejected by rejectErr, or the
+// decision has been made for a previous message of the stream.
+func (m *Metrics) throttle(ctx context.Context, meta CallMeta, enforce *callEnforcement, used bool, rejectErr error) error {
+	t := m.cfg.throttler
+	if t == nil || enforce == nil || enforce.throttled || !used || rejectErr != nil {
+		return rejectErr
+	}
+	enforce.throttled = true
+	allowed, retryAfter := t.take(t.callerKey(ctx, meta))
+	if allowed {
+		m.throttleDecisions.WithLabelValues(meta.Type, meta.Service, meta.Method, throttleAllowed).Inc()
+		return nil
+	}
+	m.throttleDecisions.WithLabelValues(meta.Type, meta.Service, meta.Method, throttleThrottled).Inc()
+	return &ThrottleError{RetryAfter: retryAfter}
+}
+
+func (m *Metrics) appendActionLabels(base []string, a action) []string {
+	if m.cfg.exemptions != nil {
+		base = append(base, exemptLabelValue(a.exempt))
+	}
+	if m.cfg.policy != nil {
+		base = append(base, string(a.stage))
+	}
+	return base
+}
+
+// reject counts a call rejected because of element, described by desc, and
+// returns its error.
+func (m *Metrics) reject(meta CallMeta, desc protoreflect.Descriptor, element Element, warning string, stage Stage) error {
+	if stage != StageBrownout {
+		return &PolicyError{Element: element, Stage: stage, warning: warning, details: DeprecationDetails(desc)}
+	}
+	m.brownoutRejected.WithLabelValues(meta.Type, meta.Service, meta.Method, string(element.Kind), string(element.Name)).Inc()
+	return &BrownoutError{Element: element, warning: warning, details: DeprecationDetails(desc)}
+}
+
+// migrate rewrites a received request if WithRequestMigration is enabled.
+func (m *Metrics) migrate(req proto.Message, meta CallMeta) {
+	if m.cfg.migration == nil {
+		return
+	}
+	m.cfg.migration.migrate(&m.reporters.Load().migrations, req.ProtoReflect(), func(from, to protoreflect.FieldDescriptor) {
+		m.fieldMigrated.WithLabelValues(meta.Type, meta.Service, meta.Method, string(from.FullName()), string(to.FullName())).Inc()
+	})
+}
+
+func (m *Metrics) track(ctx context.Context, meta CallMeta, element Element) {
+	if m.cfg.tracker != nil {
+		m.cfg.tracker.record(ctx, meta, element)
+	}
+	if m.cfg.hitters != nil {
+		m.cfg.hitters.record(ctx, meta, element)
+	}
+}
+
+// labelContext returns ctx carrying the descriptor of the deprecated element
+// for LabelValueFuncs, see DeprecatedDescriptor.
+func (m *Metrics) labelContext(ctx context.Context, reporters *reporters, desc protoreflect.Descriptor) context.Context {
+	if !m.hasLabelFuncs {
+		return ctx
+	}
+	return context.WithValue(ctx, deprecatedDescriptorKey{}, labelScope{desc: desc, reporters: reporters})
 }
 
 func (m *Metrics) buildLabelValues(
@@ -183,27 +476,32 @@ func (m *Metrics) increment(c *prometheus.CounterVec, lvs []string, exemplar pro
 	}
 }
 
-func resolvePrewarm(seedDesc []grpc.ServiceDesc) ([]protoreflect.ServiceDescriptor, []protoreflect.MessageDescriptor) {
+func resolvePrewarm(files *protoregistry.Files, seedDesc []grpc.ServiceDesc) ([]protoreflect.ServiceDescriptor, []protoreflect.MessageDescriptor) {
 	if len(seedDesc) == 0 {
 		return nil, nil
 	}
 
-	var svcSeed []protoreflect.ServiceDescriptor
-	var msgSeed []protoreflect.MessageDescriptor
-
-	seenSvc := make(map[protoreflect.FullName]bool, len(seedDesc))
-	seenMsg := make(map[protoreflect.FullName]bool)
-
+	services := make([]protoreflect.ServiceDescriptor, 0, len(seedDesc))
 	for _, raw := range seedDesc {
-		desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(raw.ServiceName))
+		desc, err := files.FindDescriptorByName(protoreflect.FullName(raw.ServiceName))
 		if err != nil {
 			continue
 		}
-		sd, ok := desc.(protoreflect.ServiceDescriptor)
-		if !ok {
-			continue
+		if sd, ok := desc.(protoreflect.ServiceDescriptor); ok {
+			services = append(services, sd)
 		}
+	}
+	return prewarmSeed(services)
+}
+
+func prewarmSeed(services []protoreflect.ServiceDescriptor) ([]protoreflect.ServiceDescriptor, []protoreflect.MessageDescriptor) {
+	var svcSeed []protoreflect.ServiceDescriptor
+	var msgSeed []protoreflect.MessageDescriptor
+
+	seenSvc := make(map[protoreflect.FullName]bool, len(services))
+	seenMsg := make(map[protoreflect.FullName]bool)
 
+	for _, sd := range services {
 		if !seenSvc[sd.FullName()] {
 			seenSvc[sd.FullName()] = true
 			svcSeed = append(svcSeed, sd)
@@ -0,0 +1,264 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: extensions.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Level int32
+
+const (
+	Level_LEVEL_UNSPECIFIED Level = 0
+	Level_LEVEL_LOW         Level = 1
+	// Deprecated: Marked as deprecated in extensions.proto.
+	Level_LEVEL_LEGACY Level = 2
+)
+
+// Enum value maps for Level.
+var (
+	Level_name = map[int32]string{
+		0: "LEVEL_UNSPECIFIED",
+		1: "LEVEL_LOW",
+		2: "LEVEL_LEGACY",
+	}
+	Level_value = map[string]int32{
+		"LEVEL_UNSPECIFIED": 0,
+		"LEVEL_LOW":         1,
+		"LEVEL_LEGACY":      2,
+	}
+)
+
+func (x Level) Enum() *Level {
+	p := new(Level)
+	*p = x
+	return p
+}
+
+func (x Level) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Level) Descriptor() protoreflect.EnumDescriptor {
+	return file_extensions_proto_enumTypes[0].Descriptor()
+}
+
+func (Level) Type() protoreflect.EnumType {
+	return &file_extensions_proto_enumTypes[0]
+}
+
+func (x Level) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Do not use.
+func (x *Level) UnmarshalJSON(b []byte) error {
+	num, err := protoimpl.X.UnmarshalJSONEnum(x.Descriptor(), b)
+	if err != nil {
+		return err
+	}
+	*x = Level(num)
+	return nil
+}
+
+// Deprecated: Use Level.Descriptor instead.
+func (Level) EnumDescriptor() ([]byte, []int) {
+	return file_extensions_proto_rawDescGZIP(), []int{0}
+}
+
+type Extendable struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Name            *string                `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Child           *Extendable            `protobuf:"bytes,2,opt,name=child" json:"child,omitempty"`
+	extensionFields protoimpl.ExtensionFields
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Extendable) Reset() {
+	*x = Extendable{}
+	mi := &file_extensions_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Extendable) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Extendable) ProtoMessage() {}
+
+func (x *Extendable) ProtoReflect() protoreflect.Message {
+	mi := &file_extensions_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Extendable.ProtoReflect.Descriptor instead.
+func (*Extendable) Descriptor() ([]byte, []int) {
+	return file_extensions_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Extendable) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *Extendable) GetChild() *Extendable {
+	if x != nil {
+		return x.Child
+	}
+	return nil
+}
+
+var file_extensions_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*Extendable)(nil),
+		ExtensionType: (*string)(nil),
+		Field:         100,
+		Name:          "testdata.legacy_note",
+		Tag:           "bytes,100,opt,name=legacy_note",
+		Filename:      "extensions.proto",
+	},
+	{
+		ExtendedType:  (*Extendable)(nil),
+		ExtensionType: (*Level)(nil),
+		Field:         101,
+		Name:          "testdata.level",
+		Tag:           "varint,101,opt,name=level,enum=testdata.Level",
+		Filename:      "extensions.proto",
+	},
+	{
+		ExtendedType:  (*Extendable)(nil),
+		ExtensionType: (*Extendable)(nil),
+		Field:         102,
+		Name:          "testdata.linked",
+		Tag:           "bytes,102,opt,name=linked",
+		Filename:      "extensions.proto",
+	},
+	{
+		ExtendedType:  (*Extendable)(nil),
+		ExtensionType: ([]Level)(nil),
+		Field:         103,
+		Name:          "testdata.levels",
+		Tag:           "varint,103,rep,name=levels,enum=testdata.Level",
+		Filename:      "extensions.proto",
+	},
+}
+
+// Extension fields to Extendable.
+var (
+	// optional string legacy_note = 100;
+	//
+	// Deprecated: Marked as deprecated in extensions.proto.
+	E_LegacyNote = &file_extensions_proto_extTypes[0]
+	// optional testdata.Level level = 101;
+	E_Level = &file_extensions_proto_extTypes[1]
+	// optional testdata.Extendable linked = 102;
+	E_Linked = &file_extensions_proto_extTypes[2]
+	// repeated testdata.Level levels = 103;
+	E_Levels = &file_extensions_proto_extTypes[3]
+)
+
+var File_extensions_proto protoreflect.FileDescriptor
+
+const file_extensions_proto_rawDesc = "" +
+	"\n" +
+	"\x10extensions.proto\x12\btestdata\"S\n" +
+	"\n" +
+	"Extendable\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12*\n" +
+	"\x05child\x18\x02 \x01(\v2\x14.testdata.ExtendableR\x05child*\x05\bd\x10\xc8\x01*C\n" +
+	"\x05Level\x12\x15\n" +
+	"\x11LEVEL_UNSPECIFIED\x10\x00\x12\r\n" +
+	"\tLEVEL_LOW\x10\x01\x12\x14\n" +
+	"\fLEVEL_LEGACY\x10\x02\x1a\x02\b\x01:9\n" +
+	"\vlegacy_note\x12\x14.testdata.Extendable\x18d \x01(\tB\x02\x18\x01R\n" +
+	"legacyNote:;\n" +
+	"\x05level\x12\x14.testdata.Extendable\x18e \x01(\x0e2\x0f.testdata.LevelR\x05level:B\n" +
+	"\x06linked\x12\x14.testdata.Extendable\x18f \x01(\v2\x14.testdata.ExtendableR\x06linked:=\n" +
+	"\x06levels\x12\x14.testdata.Extendable\x18g \x03(\x0e2\x0f.testdata.LevelR\x06levelsB\xa9\x01\n" +
+	"\fcom.testdataB\x0fExtensionsProtoP\x01ZHgithub.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto;pb\xa2\x02\x03TXX\xaa\x02\bTestdata\xca\x02\bTestdata\xe2\x02\x14Testdata\\GPBMetadata\xea\x02\bTestdata"
+
+var (
+	file_extensions_proto_rawDescOnce sync.Once
+	file_extensions_proto_rawDescData []byte
+)
+
+func file_extensions_proto_rawDescGZIP() []byte {
+	file_extensions_proto_rawDescOnce.Do(func() {
+		file_extensions_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_extensions_proto_rawDesc), len(file_extensions_proto_rawDesc)))
+	})
+	return file_extensions_proto_rawDescData
+}
+
+var file_extensions_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_extensions_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_extensions_proto_goTypes = []any{
+	(Level)(0),         // 0: testdata.Level
+	(*Extendable)(nil), // 1: testdata.Extendable
+}
+var file_extensions_proto_depIdxs = []int32{
+	1, // 0: testdata.Extendable.child:type_name -> testdata.Extendable
+	1, // 1: testdata.legacy_note:extendee -> testdata.Extendable
+	1, // 2: testdata.level:extendee -> testdata.Extendable
+	1, // 3: testdata.linked:extendee -> testdata.Extendable
+	1, // 4: testdata.levels:extendee -> testdata.Extendable
+	0, // 5: testdata.level:type_name -> testdata.Level
+	1, // 6: testdata.linked:type_name -> testdata.Extendable
+	0, // 7: testdata.levels:type_name -> testdata.Level
+	8, // [8:8] is the sub-list for method output_type
+	8, // [8:8] is the sub-list for method input_type
+	5, // [5:8] is the sub-list for extension type_name
+	1, // [1:5] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_extensions_proto_init() }
+func file_extensions_proto_init() {
+	if File_extensions_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_extensions_proto_rawDesc), len(file_extensions_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   1,
+			NumExtensions: 4,
+			NumServices:   0,
+		},
+		GoTypes:           file_extensions_proto_goTypes,
+		DependencyIndexes: file_extensions_proto_depIdxs,
+		EnumInfos:         file_extensions_proto_enumTypes,
+		MessageInfos:      file_extensions_proto_msgTypes,
+		ExtensionInfos:    file_extensions_proto_extTypes,
+	}.Build()
+	File_extensions_proto = out.File
+	file_extensions_proto_goTypes = nil
+	file_extensions_proto_depIdxs = nil
+}
@@ -0,0 +1,1040 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: service.proto
+
+package pb
+
+import (
+	_ "github.com/belo4ya/grpc-api-deprecation/annotations"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Visibility int32
+
+const (
+	// Deprecated: Marked as deprecated in service.proto.
+	Visibility_VISIBILITY_LEGACY_DEFAULT Visibility = 0
+	Visibility_VISIBILITY_PUBLIC         Visibility = 1
+	Visibility_VISIBILITY_PRIVATE        Visibility = 2
+)
+
+// Enum value maps for Visibility.
+var (
+	Visibility_name = map[int32]string{
+		0: "VISIBILITY_LEGACY_DEFAULT",
+		1: "VISIBILITY_PUBLIC",
+		2: "VISIBILITY_PRIVATE",
+	}
+	Visibility_value = map[string]int32{
+		"VISIBILITY_LEGACY_DEFAULT": 0,
+		"VISIBILITY_PUBLIC":         1,
+		"VISIBILITY_PRIVATE":        2,
+	}
+)
+
+func (x Visibility) Enum() *Visibility {
+	p := new(Visibility)
+	*p = x
+	return p
+}
+
+func (x Visibility) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Visibility) Descriptor() protoreflect.EnumDescriptor {
+	return file_service_proto_enumTypes[0].Descriptor()
+}
+
+func (Visibility) Type() protoreflect.EnumType {
+	return &file_service_proto_enumTypes[0]
+}
+
+func (x Visibility) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Visibility.Descriptor instead.
+func (Visibility) EnumDescriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{0}
+}
+
+type Priority int32
+
+const (
+	Priority_PRIORITY_UNSPECIFIED Priority = 0
+	Priority_PRIORITY_HIGH        Priority = 1
+	// Deprecated: Marked as deprecated in service.proto.
+	Priority_PRIORITY_URGENT Priority = 1
+)
+
+// Enum value maps for Priority.
+var (
+	Priority_name = map[int32]string{
+		0: "PRIORITY_UNSPECIFIED",
+		1: "PRIORITY_HIGH",
+		// Duplicate value: 1: "PRIORITY_URGENT",
+	}
+	Priority_value = map[string]int32{
+		"PRIORITY_UNSPECIFIED": 0,
+		"PRIORITY_HIGH":        1,
+		"PRIORITY_URGENT":      1,
+	}
+)
+
+func (x Priority) Enum() *Priority {
+	p := new(Priority)
+	*p = x
+	return p
+}
+
+func (x Priority) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Priority) Descriptor() protoreflect.EnumDescriptor {
+	return file_service_proto_enumTypes[1].Descriptor()
+}
+
+func (Priority) Type() protoreflect.EnumType {
+	return &file_service_proto_enumTypes[1]
+}
+
+func (x Priority) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Priority.Descriptor instead.
+func (Priority) EnumDescriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{1}
+}
+
+type State int32
+
+const (
+	State_STATE_UNSPECIFIED State = 0
+	State_STATE_ACTIVE      State = 1
+	// Deprecated: Marked as deprecated in service.proto.
+	State_STATE_LEGACY State = 2
+)
+
+// Enum value maps for State.
+var (
+	State_name = map[int32]string{
+		0: "STATE_UNSPECIFIED",
+		1: "STATE_ACTIVE",
+		2: "STATE_LEGACY",
+	}
+	State_value = map[string]int32{
+		"STATE_UNSPECIFIED": 0,
+		"STATE_ACTIVE":      1,
+		"STATE_LEGACY":      2,
+	}
+)
+
+func (x State) Enum() *State {
+	p := new(State)
+	*p = x
+	return p
+}
+
+func (x State) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (State) Descriptor() protoreflect.EnumDescriptor {
+	return file_service_proto_enumTypes[2].Descriptor()
+}
+
+func (State) Type() protoreflect.EnumType {
+	return &file_service_proto_enumTypes[2]
+}
+
+func (x State) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use State.Descriptor instead.
+func (State) EnumDescriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{2}
+}
+
+type Resource struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Name        string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	DisplayName string                 `protobuf:"bytes,2,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	State       State                  `protobuf:"varint,3,opt,name=state,proto3,enum=testdata.State" json:"state,omitempty"`
+	Children    []*Resource            `protobuf:"bytes,4,rep,name=children,proto3" json:"children,omitempty"`
+	States      map[string]State       `protobuf:"bytes,5,rep,name=states,proto3" json:"states,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value,enum=testdata.State"`
+	Labels      *LegacyLabels          `protobuf:"bytes,6,opt,name=labels,proto3" json:"labels,omitempty"`
+	// Deprecated: Marked as deprecated in service.proto.
+	Title         string `protobuf:"bytes,101,opt,name=title,proto3" json:"title,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Resource) Reset() {
+	*x = Resource{}
+	mi := &file_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Resource) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Resource) ProtoMessage() {}
+
+func (x *Resource) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Resource.ProtoReflect.Descriptor instead.
+func (*Resource) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Resource) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Resource) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *Resource) GetState() State {
+	if x != nil {
+		return x.State
+	}
+	return State_STATE_UNSPECIFIED
+}
+
+func (x *Resource) GetChildren() []*Resource {
+	if x != nil {
+		return x.Children
+	}
+	return nil
+}
+
+func (x *Resource) GetStates() map[string]State {
+	if x != nil {
+		return x.States
+	}
+	return nil
+}
+
+func (x *Resource) GetLabels() *LegacyLabels {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+// Deprecated: Marked as deprecated in service.proto.
+func (x *Resource) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in service.proto.
+type LegacyLabels struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        map[string]string      `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LegacyLabels) Reset() {
+	*x = LegacyLabels{}
+	mi := &file_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LegacyLabels) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LegacyLabels) ProtoMessage() {}
+
+func (x *LegacyLabels) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LegacyLabels.ProtoReflect.Descriptor instead.
+func (*LegacyLabels) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LegacyLabels) GetValues() map[string]string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type Owner struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Id:
+	//
+	//	*Owner_Email
+	//	*Owner_UserId
+	//	*Owner_Login
+	Id isOwner_Id `protobuf_oneof:"id"`
+	// Types that are valid to be assigned to LegacyId:
+	//
+	//	*Owner_LegacyNumber
+	//	*Owner_LegacyLogin
+	LegacyId      isOwner_LegacyId `protobuf_oneof:"legacy_id"`
+	DisplayName   *string          `protobuf:"bytes,6,opt,name=display_name,json=displayName,proto3,oneof" json:"display_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Owner) Reset() {
+	*x = Owner{}
+	mi := &file_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Owner) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Owner) ProtoMessage() {}
+
+func (x *Owner) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Owner.ProtoReflect.Descriptor instead.
+func (*Owner) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Owner) GetId() isOwner_Id {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *Owner) GetEmail() string {
+	if x != nil {
+		if x, ok := x.Id.(*Owner_Email); ok {
+			return x.Email
+		}
+	}
+	return ""
+}
+
+func (x *Owner) GetUserId() string {
+	if x != nil {
+		if x, ok := x.Id.(*Owner_UserId); ok {
+			return x.UserId
+		}
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in service.proto.
+func (x *Owner) GetLogin() string {
+	if x != nil {
+		if x, ok := x.Id.(*Owner_Login); ok {
+			return x.Login
+		}
+	}
+	return ""
+}
+
+func (x *Owner) GetLegacyId() isOwner_LegacyId {
+	if x != nil {
+		return x.LegacyId
+	}
+	return nil
+}
+
+func (x *Owner) GetLegacyNumber() int64 {
+	if x != nil {
+		if x, ok := x.LegacyId.(*Owner_LegacyNumber); ok {
+			return x.LegacyNumber
+		}
+	}
+	return 0
+}
+
+func (x *Owner) GetLegacyLogin() string {
+	if x != nil {
+		if x, ok := x.LegacyId.(*Owner_LegacyLogin); ok {
+			return x.LegacyLogin
+		}
+	}
+	return ""
+}
+
+func (x *Owner) GetDisplayName() string {
+	if x != nil && x.DisplayName != nil {
+		return *x.DisplayName
+	}
+	return ""
+}
+
+type isOwner_Id interface {
+	isOwner_Id()
+}
+
+type Owner_Email struct {
+	Email string `protobuf:"bytes,1,opt,name=email,proto3,oneof"`
+}
+
+type Owner_UserId struct {
+	UserId string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3,oneof"`
+}
+
+type Owner_Login struct {
+	// Deprecated: Marked as deprecated in service.proto.
+	Login string `protobuf:"bytes,3,opt,name=login,proto3,oneof"`
+}
+
+func (*Owner_Email) isOwner_Id() {}
+
+func (*Owner_UserId) isOwner_Id() {}
+
+func (*Owner_Login) isOwner_Id() {}
+
+type isOwner_LegacyId interface {
+	isOwner_LegacyId()
+}
+
+type Owner_LegacyNumber struct {
+	LegacyNumber int64 `protobuf:"varint,4,opt,name=legacy_number,json=legacyNumber,proto3,oneof"`
+}
+
+type Owner_LegacyLogin struct {
+	LegacyLogin string `protobuf:"bytes,5,opt,name=legacy_login,json=legacyLogin,proto3,oneof"`
+}
+
+func (*Owner_LegacyNumber) isOwner_LegacyId() {}
+
+func (*Owner_LegacyLogin) isOwner_LegacyId() {}
+
+type Contact struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Email       string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	Phones      []string               `protobuf:"bytes,2,rep,name=phones,proto3" json:"phones,omitempty"`
+	DisplayName string                 `protobuf:"bytes,3,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Children    []*Contact             `protobuf:"bytes,4,rep,name=children,proto3" json:"children,omitempty"`
+	// Deprecated: Marked as deprecated in service.proto.
+	Mail string `protobuf:"bytes,101,opt,name=mail,proto3" json:"mail,omitempty"`
+	// Deprecated: Marked as deprecated in service.proto.
+	Phone string `protobuf:"bytes,102,opt,name=phone,proto3" json:"phone,omitempty"`
+	// Deprecated: Marked as deprecated in service.proto.
+	Name          string `protobuf:"bytes,103,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Contact) Reset() {
+	*x = Contact{}
+	mi := &file_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Contact) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Contact) ProtoMessage() {}
+
+func (x *Contact) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Contact.ProtoReflect.Descriptor instead.
+func (*Contact) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Contact) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *Contact) GetPhones() []string {
+	if x != nil {
+		return x.Phones
+	}
+	return nil
+}
+
+func (x *Contact) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *Contact) GetChildren() []*Contact {
+	if x != nil {
+		return x.Children
+	}
+	return nil
+}
+
+// Deprecated: Marked as deprecated in service.proto.
+func (x *Contact) GetMail() string {
+	if x != nil {
+		return x.Mail
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in service.proto.
+func (x *Contact) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in service.proto.
+func (x *Contact) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type Settings struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Visibility        Visibility             `protobuf:"varint,1,opt,name=visibility,proto3,enum=testdata.Visibility" json:"visibility,omitempty"`
+	DefaultVisibility *Visibility            `protobuf:"varint,2,opt,name=default_visibility,json=defaultVisibility,proto3,enum=testdata.Visibility,oneof" json:"default_visibility,omitempty"`
+	Visibilities      []Visibility           `protobuf:"varint,3,rep,packed,name=visibilities,proto3,enum=testdata.Visibility" json:"visibilities,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Settings) Reset() {
+	*x = Settings{}
+	mi := &file_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Settings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Settings) ProtoMessage() {}
+
+func (x *Settings) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Settings.ProtoReflect.Descriptor instead.
+func (*Settings) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Settings) GetVisibility() Visibility {
+	if x != nil {
+		return x.Visibility
+	}
+	return Visibility_VISIBILITY_LEGACY_DEFAULT
+}
+
+func (x *Settings) GetDefaultVisibility() Visibility {
+	if x != nil && x.DefaultVisibility != nil {
+		return *x.DefaultVisibility
+	}
+	return Visibility_VISIBILITY_LEGACY_DEFAULT
+}
+
+func (x *Settings) GetVisibilities() []Visibility {
+	if x != nil {
+		return x.Visibilities
+	}
+	return nil
+}
+
+type GetResourceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	ReadMask      *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=read_mask,json=readMask,proto3" json:"read_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetResourceRequest) Reset() {
+	*x = GetResourceRequest{}
+	mi := &file_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetResourceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetResourceRequest) ProtoMessage() {}
+
+func (x *GetResourceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetResourceRequest.ProtoReflect.Descriptor instead.
+func (*GetResourceRequest) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetResourceRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *GetResourceRequest) GetReadMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.ReadMask
+	}
+	return nil
+}
+
+type ListResourcesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Parent        string                 `protobuf:"bytes,1,opt,name=parent,proto3" json:"parent,omitempty"`
+	ReadMask      *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=read_mask,json=readMask,proto3" json:"read_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResourcesRequest) Reset() {
+	*x = ListResourcesRequest{}
+	mi := &file_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResourcesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResourcesRequest) ProtoMessage() {}
+
+func (x *ListResourcesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResourcesRequest.ProtoReflect.Descriptor instead.
+func (*ListResourcesRequest) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListResourcesRequest) GetParent() string {
+	if x != nil {
+		return x.Parent
+	}
+	return ""
+}
+
+func (x *ListResourcesRequest) GetReadMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.ReadMask
+	}
+	return nil
+}
+
+type ListResourcesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Resources     []*Resource            `protobuf:"bytes,1,rep,name=resources,proto3" json:"resources,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResourcesResponse) Reset() {
+	*x = ListResourcesResponse{}
+	mi := &file_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResourcesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResourcesResponse) ProtoMessage() {}
+
+func (x *ListResourcesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResourcesResponse.ProtoReflect.Descriptor instead.
+func (*ListResourcesResponse) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListResourcesResponse) GetResources() []*Resource {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+func (x *ListResourcesResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type UpdateResourceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Resource      *Resource              `protobuf:"bytes,1,opt,name=resource,proto3" json:"resource,omitempty"`
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	PatchMask     *fieldmaskpb.FieldMask `protobuf:"bytes,3,opt,name=patch_mask,json=patchMask,proto3" json:"patch_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateResourceRequest) Reset() {
+	*x = UpdateResourceRequest{}
+	mi := &file_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateResourceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateResourceRequest) ProtoMessage() {}
+
+func (x *UpdateResourceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateResourceRequest.ProtoReflect.Descriptor instead.
+func (*UpdateResourceRequest) Descriptor() ([]byte, []int) {
+	return file_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateResourceRequest) GetResource() *Resource {
+	if x != nil {
+		return x.Resource
+	}
+	return nil
+}
+
+func (x *UpdateResourceRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+func (x *UpdateResourceRequest) GetPatchMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.PatchMask
+	}
+	return nil
+}
+
+var File_service_proto protoreflect.FileDescriptor
+
+const file_service_proto_rawDesc = "" +
+	"\n" +
+	"\rservice.proto\x12\btestdata\x1a\x11annotations.proto\x1a\x1cgoogle/api/annotations.proto\x1a google/protobuf/field_mask.proto\"\x90\x03\n" +
+	"\bResource\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\fdisplay_name\x18\x02 \x01(\tR\vdisplayName\x12%\n" +
+	"\x05state\x18\x03 \x01(\x0e2\x0f.testdata.StateR\x05state\x12.\n" +
+	"\bchildren\x18\x04 \x03(\v2\x12.testdata.ResourceR\bchildren\x126\n" +
+	"\x06states\x18\x05 \x03(\v2\x1e.testdata.Resource.StatesEntryR\x06states\x12.\n" +
+	"\x06labels\x18\x06 \x01(\v2\x16.testdata.LegacyLabelsR\x06labels\x12B\n" +
+	"\x05title\x18e \x01(\tB,\xd2J'\n" +
+	"\n" +
+	"2025-03-01\x12\x19Use display_name instead.\x18\x01R\x05title\x1aJ\n" +
+	"\vStatesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12%\n" +
+	"\x05value\x18\x02 \x01(\x0e2\x0f.testdata.StateR\x05value:\x028\x01\"\xbc\x01\n" +
+	"\fLegacyLabels\x12:\n" +
+	"\x06values\x18\x01 \x03(\v2\".testdata.LegacyLabels.ValuesEntryR\x06values\x1a9\n" +
+	"\vValuesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01:5\xd2J0\n" +
+	"\n" +
+	"2025-05-01\x12\"Use Resource.display_name instead.\x18\x01\"\x93\x02\n" +
+	"\x05Owner\x12\x16\n" +
+	"\x05email\x18\x01 \x01(\tH\x00R\x05email\x12\x19\n" +
+	"\auser_id\x18\x02 \x01(\tH\x00R\x06userId\x12\x1a\n" +
+	"\x05login\x18\x03 \x01(\tB\x02\x18\x01H\x00R\x05login\x12%\n" +
+	"\rlegacy_number\x18\x04 \x01(\x03H\x01R\flegacyNumber\x12#\n" +
+	"\flegacy_login\x18\x05 \x01(\tH\x01R\vlegacyLogin\x12&\n" +
+	"\fdisplay_name\x18\x06 \x01(\tH\x02R\vdisplayName\x88\x01\x01B\x04\n" +
+	"\x02idB0\n" +
+	"\tlegacy_id\x12#\xd2J\x1d\n" +
+	"\n" +
+	"2025-07-01\x12\x0fUse id instead.\xd8J\x01B\x0f\n" +
+	"\r_display_name\"\xa0\x02\n" +
+	"\aContact\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x16\n" +
+	"\x06phones\x18\x02 \x03(\tR\x06phones\x12!\n" +
+	"\fdisplay_name\x18\x03 \x01(\tR\vdisplayName\x12-\n" +
+	"\bchildren\x18\x04 \x03(\v2\x11.testdata.ContactR\bchildren\x121\n" +
+	"\x04mail\x18e \x01(\tB\x1d\xd2J\x18\"\x16testdata.Contact.email\x18\x01R\x04mail\x124\n" +
+	"\x05phone\x18f \x01(\tB\x1e\xd2J\x19\"\x17testdata.Contact.phones\x18\x01R\x05phone\x12\x16\n" +
+	"\x04name\x18g \x01(\tB\x02\x18\x01R\x04nameJ\x04\b\x05\x10\x06R\x03faxR\thome_page\"\xdb\x01\n" +
+	"\bSettings\x124\n" +
+	"\n" +
+	"visibility\x18\x01 \x01(\x0e2\x14.testdata.VisibilityR\n" +
+	"visibility\x12H\n" +
+	"\x12default_visibility\x18\x02 \x01(\x0e2\x14.testdata.VisibilityH\x00R\x11defaultVisibility\x88\x01\x01\x128\n" +
+	"\fvisibilities\x18\x03 \x03(\x0e2\x14.testdata.VisibilityR\fvisibilitiesB\x15\n" +
+	"\x13_default_visibility\"a\n" +
+	"\x12GetResourceRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x127\n" +
+	"\tread_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\breadMask\"g\n" +
+	"\x14ListResourcesRequest\x12\x16\n" +
+	"\x06parent\x18\x01 \x01(\tR\x06parent\x127\n" +
+	"\tread_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\breadMask\"q\n" +
+	"\x15ListResourcesResponse\x120\n" +
+	"\tresources\x18\x01 \x03(\v2\x12.testdata.ResourceR\tresources\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xcc\x01\n" +
+	"\x15UpdateResourceRequest\x12.\n" +
+	"\bresource\x18\x01 \x01(\v2\x12.testdata.ResourceR\bresource\x12;\n" +
+	"\vupdate_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\x12F\n" +
+	"\n" +
+	"patch_mask\x18\x03 \x01(\v2\x1a.google.protobuf.FieldMaskB\v\xdaJ\bresourceR\tpatchMask*^\n" +
+	"\n" +
+	"Visibility\x12!\n" +
+	"\x19VISIBILITY_LEGACY_DEFAULT\x10\x00\x1a\x02\b\x01\x12\x15\n" +
+	"\x11VISIBILITY_PUBLIC\x10\x01\x12\x16\n" +
+	"\x12VISIBILITY_PRIVATE\x10\x02*T\n" +
+	"\bPriority\x12\x18\n" +
+	"\x14PRIORITY_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rPRIORITY_HIGH\x10\x01\x12\x17\n" +
+	"\x0fPRIORITY_URGENT\x10\x01\x1a\x02\b\x01\x1a\x02\x10\x01*p\n" +
+	"\x05State\x12\x15\n" +
+	"\x11STATE_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fSTATE_ACTIVE\x10\x01\x12>\n" +
+	"\fSTATE_LEGACY\x10\x02\x1a,\xd2J'\n" +
+	"\n" +
+	"2025-04-01\x12\x19Use STATE_ACTIVE instead.\b\x012\x97\x04\n" +
+	"\x0fResourceService\x12_\n" +
+	"\vGetResource\x12\x1c.testdata.GetResourceRequest\x1a\x12.testdata.Resource\"\x1e\x82\xd3\xe4\x93\x02\x18\x12\x16/v1/{name=resources/*}\x12x\n" +
+	"\x0eUpdateResource\x12\x1f.testdata.UpdateResourceRequest\x1a\x12.testdata.Resource\"1\x82\xd3\xe4\x93\x02+:\bresource2\x1f/v1/{resource.name=resources/*}\x12\x98\x01\n" +
+	"\x11GetResourceLegacy\x12\x1c.testdata.GetResourceRequest\x1a\x12.testdata.Resource\"Q\xd2J&\n" +
+	"\n" +
+	"2025-06-01\x12\x18Use GetResource instead.\x82\xd3\xe4\x93\x02\x1f\x12\x1d/v1/legacy/{name=resources/*}\x88\x02\x01\x12<\n" +
+	"\x0eWatchResources\x12\x12.testdata.Resource\x1a\x12.testdata.Resource(\x010\x01\x12P\n" +
+	"\rListResources\x12\x1e.testdata.ListResourcesRequest\x1a\x1f.testdata.ListResourcesResponse2\xd5\x02\n" +
+	"\x15LegacyResourceService\x12?\n" +
+	"\vGetResource\x12\x1c.testdata.GetResourceRequest\x1a\x12.testdata.Resource\x1a\xfa\x01\xd2J\xf3\x01\n" +
+	"\n" +
+	"2025-01-01\x12\x1cUse ResourceService instead.\x1a,\n" +
+	"\x142024-12-01T00:00:00Z\x12\x142024-12-02T00:00:00Z\x1a5\n" +
+	"\x142024-12-15T00:00:00Z\x12\x142024-12-16T00:00:00Z\x19\x00\x00\x00\x00\x00\x00I@\"\x18testdata.ResourceService*0https://example.com/docs/legacy-resource-service2\x0eteam-resources:\x06API-42\x88\x02\x01B\xa6\x01\n" +
+	"\fcom.testdataB\fServiceProtoP\x01ZHgithub.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto;pb\xa2\x02\x03TXX\xaa\x02\bTestdata\xca\x02\bTestdata\xe2\x02\x14Testdata\\GPBMetadata\xea\x02\bTestdatab\x06proto3"
+
+var (
+	file_service_proto_rawDescOnce sync.Once
+	file_service_proto_rawDescData []byte
+)
+
+func file_service_proto_rawDescGZIP() []byte {
+	file_service_proto_rawDescOnce.Do(func() {
+		file_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_service_proto_rawDesc), len(file_service_proto_rawDesc)))
+	})
+	return file_service_proto_rawDescData
+}
+
+var file_service_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_service_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_service_proto_goTypes = []any{
+	(Visibility)(0),               // 0: testdata.Visibility
+	(Priority)(0),                 // 1: testdata.Priority
+	(State)(0),                    // 2: testdata.State
+	(*Resource)(nil),              // 3: testdata.Resource
+	(*LegacyLabels)(nil),          // 4: testdata.LegacyLabels
+	(*Owner)(nil),                 // 5: testdata.Owner
+	(*Contact)(nil),               // 6: testdata.Contact
+	(*Settings)(nil),              // 7: testdata.Settings
+	(*GetResourceRequest)(nil),    // 8: testdata.GetResourceRequest
+	(*ListResourcesRequest)(nil),  // 9: testdata.ListResourcesRequest
+	(*ListResourcesResponse)(nil), // 10: testdata.ListResourcesResponse
+	(*UpdateResourceRequest)(nil), // 11: testdata.UpdateResourceRequest
+	nil,                           // 12: testdata.Resource.StatesEntry
+	nil,                           // 13: testdata.LegacyLabels.ValuesEntry
+	(*fieldmaskpb.FieldMask)(nil), // 14: google.protobuf.FieldMask
+}
+var file_service_proto_depIdxs = []int32{
+	2,  // 0: testdata.Resource.state:type_name -> testdata.State
+	3,  // 1: testdata.Resource.children:type_name -> testdata.Resource
+	12, // 2: testdata.Resource.states:type_name -> testdata.Resource.StatesEntry
+	4,  // 3: testdata.Resource.labels:type_name -> testdata.LegacyLabels
+	13, // 4: testdata.LegacyLabels.values:type_name -> testdata.LegacyLabels.ValuesEntry
+	6,  // 5: testdata.Contact.children:type_name -> testdata.Contact
+	0,  // 6: testdata.Settings.visibility:type_name -> testdata.Visibility
+	0,  // 7: testdata.Settings.default_visibility:type_name -> testdata.Visibility
+	0,  // 8: testdata.Settings.visibilities:type_name -> testdata.Visibility
+	14, // 9: testdata.GetResourceRequest.read_mask:type_name -> google.protobuf.FieldMask
+	14, // 10: testdata.ListResourcesRequest.read_mask:type_name -> google.protobuf.FieldMask
+	3,  // 11: testdata.ListResourcesResponse.resources:type_name -> testdata.Resource
+	3,  // 12: testdata.UpdateResourceRequest.resource:type_name -> testdata.Resource
+	14, // 13: testdata.UpdateResourceRequest.update_mask:type_name -> google.protobuf.FieldMask
+	14, // 14: testdata.UpdateResourceRequest.patch_mask:type_name -> google.protobuf.FieldMask
+	2,  // 15: testdata.Resource.StatesEntry.value:type_name -> testdata.State
+	8,  // 16: testdata.ResourceService.GetResource:input_type -> testdata.GetResourceRequest
+	11, // 17: testdata.ResourceService.UpdateResource:input_type -> testdata.UpdateResourceRequest
+	8,  // 18: testdata.ResourceService.GetResourceLegacy:input_type -> testdata.GetResourceRequest
+	3,  // 19: testdata.ResourceService.WatchResources:input_type -> testdata.Resource
+	9,  // 20: testdata.ResourceService.ListResources:input_type -> testdata.ListResourcesRequest
+	8,  // 21: testdata.LegacyResourceService.GetResource:input_type -> testdata.GetResourceRequest
+	3,  // 22: testdata.ResourceService.GetResource:output_type -> testdata.Resource
+	3,  // 23: testdata.ResourceService.UpdateResource:output_type -> testdata.Resource
+	3,  // 24: testdata.ResourceService.GetResourceLegacy:output_type -> testdata.Resource
+	3,  // 25: testdata.ResourceService.WatchResources:output_type -> testdata.Resource
+	10, // 26: testdata.ResourceService.ListResources:output_type -> testdata.ListResourcesResponse
+	3,  // 27: testdata.LegacyResourceService.GetResource:output_type -> testdata.Resource
+	22, // [22:28] is the sub-list for method output_type
+	16, // [16:22] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_service_proto_init() }
+func file_service_proto_init() {
+	if File_service_proto != nil {
+		return
+	}
+	file_service_proto_msgTypes[2].OneofWrappers = []any{
+		(*Owner_Email)(nil),
+		(*Owner_UserId)(nil),
+		(*Owner_Login)(nil),
+		(*Owner_LegacyNumber)(nil),
+		(*Owner_LegacyLogin)(nil),
+	}
+	file_service_proto_msgTypes[4].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_service_proto_rawDesc), len(file_service_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_service_proto_goTypes,
+		DependencyIndexes: file_service_proto_depIdxs,
+		EnumInfos:         file_service_proto_enumTypes,
+		MessageInfos:      file_service_proto_msgTypes,
+	}.Build()
+	File_service_proto = out.File
+	file_service_proto_goTypes = nil
+	file_service_proto_depIdxs = nil
+}
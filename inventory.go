@@ -0,0 +1,167 @@
+package apideprecation
+
+import (
+	"cmp"
+	"slices"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// ElementKind is the kind of deprecated protobuf element.
+type ElementKind string
+
+const (
+	ElementMethod    ElementKind = "method"
+	ElementField     ElementKind = "field"
+	ElementEnumValue ElementKind = "enum_value"
+	ElementMessage   ElementKind = "message"
+)
+
+// Element identifies a deprecated protobuf element by its kind and full name.
+// Methods of deprecated services are reported as ElementMethod. Deprecated
+// message types are only used through fields referenced by FieldMasks, see
+// WithFieldMasks.
+type Element struct {
+	Kind ElementKind           `json:"kind"`
+	Name protoreflect.FullName `json:"name"`
+}
+
+func methodElement(md protoreflect.MethodDescriptor) Element {
+	return Element{Kind: ElementMethod, Name: md.FullName()}
+}
+
+func fieldElement(fd protoreflect.FieldDescriptor) Element {
+	return Element{Kind: ElementField, Name: fd.FullName()}
+}
+
+func messageElement(md protoreflect.MessageDescriptor) Element {
+	return Element{Kind: ElementMessage, Name: md.FullName()}
+}
+
+// deprecatedFieldElement returns the element of a reported field: the field
+// itself, or its deprecated message type, see deprecatedFieldDescriptor.
+func deprecatedFieldElement(fd protoreflect.FieldDescriptor) Element {
+	if md, ok := deprecatedFieldDescriptor(fd).(protoreflect.MessageDescriptor); ok {
+		return messageElement(md)
+	}
+	return fieldElement(fd)
+}
+
+func enumValueElement(evd protoreflect.EnumValueDescriptor) Element {
+	return Element{Kind: ElementEnumValue, Name: evd.FullName()}
+}
+
+func compareElements(a, b Element) int {
+	return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Name, b.Name))
+}
+
+// Inventory returns every deprecated method, field, enum value, and message
+// type registered in files, sorted by kind and full name. If files is nil,
+// protoregistry.GlobalFiles is used.
+func Inventory(files *protoregistry.Files) []Element {
+	if files == nil {
+		files = protoregistry.GlobalFiles
+	}
+	var elements []Element
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		elements = appendFileInventory(elements, fd)
+		return true
+	})
+	slices.SortFunc(elements, compareElements)
+	return slices.CompactFunc(elements, func(a, b Element) bool { return a == b })
+}
+
+// ElementDetails is a deprecated element with its DeprecationDetails.
+type ElementDetails struct {
+	Element
+	EffectiveAt string `json:"effective_at,omitempty"`
+	Description string `json:"description,omitempty"`
+	Replacement string `json:"replacement,omitempty"`
+	DocsURL     string `json:"docs_url,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+	Ticket      string `json:"ticket,omitempty"`
+}
+
+// InventoryDetails returns the Inventory of files with the deprecation details
+// of every element. If files is nil, protoregistry.GlobalFiles is used.
+func InventoryDetails(files *protoregistry.Files) []ElementDetails {
+	if files == nil {
+		files = protoregistry.GlobalFiles
+	}
+	elements := Inventory(files)
+	inventory := make([]ElementDetails, 0, len(elements))
+	for _, element := range elements {
+		e := ElementDetails{Element: element}
+		if desc, err := files.FindDescriptorByName(element.Name); err == nil {
+			details := DeprecationDetails(desc)
+			e.EffectiveAt = details.GetEffectiveAt()
+			e.Description = details.GetDescription()
+			e.Replacement = details.GetReplacement()
+			e.DocsURL = details.GetDocsUrl()
+			e.Owner = details.GetOwner()
+			e.Ticket = details.GetTicket()
+		}
+		inventory = append(inventory, e)
+	}
+	return inventory
+}
+
+func appendFileInventory(elements []Element, fd protoreflect.FileDescriptor) []Element {
+	services := fd.Services()
+	for i := range services.Len() {
+		sd := services.Get(i)
+		serviceDeprecated := isServiceDeprecated(sd)
+		methods := sd.Methods()
+		for j := range methods.Len() {
+			if md := methods.Get(j); serviceDeprecated || isMethodDeprecated(md) {
+				elements = append(elements, methodElement(md))
+			}
+		}
+	}
+	elements = appendMessagesInventory(elements, fd.Messages())
+	elements = appendEnumsInventory(elements, fd.Enums())
+	elements = appendFieldsInventory(elements, fd.Extensions())
+	return elements
+}
+
+func appendMessagesInventory(elements []Element, messages protoreflect.MessageDescriptors) []Element {
+	for i := range messages.Len() {
+		md := messages.Get(i)
+		if isMessageDeprecated(md) {
+			elements = append(elements, messageElement(md))
+		}
+		elements = appendFieldsInventory(elements, md.Fields())
+		elements = appendFieldsInventory(elements, md.Extensions())
+		elements = appendEnumsInventory(elements, md.Enums())
+		elements = appendMessagesInventory(elements, md.Messages())
+	}
+	return elements
+}
+
+// fieldList is implemented by both protoreflect.FieldDescriptors and protoreflect.ExtensionDescriptors.
+type fieldList interface {
+	Len() int
+	Get(i int) protoreflect.FieldDescriptor
+}
+
+func appendFieldsInventory(elements []Element, fields fieldList) []Element {
+	for i := range fields.Len() {
+		if fd := fields.Get(i); isFieldDeprecated(fd) {
+			elements = append(elements, fieldElement(fd))
+		}
+	}
+	return elements
+}
+
+func appendEnumsInventory(elements []Element, enums protoreflect.EnumDescriptors) []Element {
+	for i := range enums.Len() {
+		values := enums.Get(i).Values()
+		for j := range values.Len() {
+			if evd := values.Get(j); isEnumValueDeprecated(evd) {
+				elements = append(elements, enumValueElement(evd))
+			}
+		}
+	}
+	return elements
+}
@@ -10,11 +10,12 @@ import (
 )
 
 type methodReporter struct {
+	files *protoregistry.Files
 	cache sync.Map // fullMethod -> methodCacheEntry
 }
 
-func newMethodReporter(seedDesc []protoreflect.ServiceDescriptor) *methodReporter {
-	r := &methodReporter{}
+func newMethodReporter(files *protoregistry.Files, seedDesc []protoreflect.ServiceDescriptor) *methodReporter {
+	r := &methodReporter{files: files}
 	for _, sd := range seedDesc {
 		service := string(sd.FullName())
 		methods := sd.Methods()
@@ -49,7 +50,7 @@ func (r *methodReporter) getOrResolve(fullMethod string) methodCacheEntry {
 }
 
 func (r *methodReporter) resolveDescriptor(fullMethod string) methodCacheEntry {
-	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(r.fullMethodToName(fullMethod))
+	desc, err := r.files.FindDescriptorByName(fullMethodToName(fullMethod))
 	if err != nil {
 		return methodCacheEntry{deprecated: false}
 	}
@@ -57,18 +58,18 @@ func (r *methodReporter) resolveDescriptor(fullMethod string) methodCacheEntry {
 	if !ok {
 		return methodCacheEntry{deprecated: false}
 	}
-	if !r.isMethodOrServiceDeprecated(md) {
+	if !isMethodOrServiceDeprecated(md) {
 		return methodCacheEntry{deprecated: false}
 	}
 	return methodCacheEntry{deprecated: true, md: md}
 }
 
-func (r *methodReporter) fullMethodToName(fullMethod string) protoreflect.FullName {
+func fullMethodToName(fullMethod string) protoreflect.FullName {
 	i := strings.LastIndexByte(fullMethod, '/')
 	return protoreflect.FullName(fullMethod[1:i] + "." + fullMethod[i+1:])
 }
 
-func (r *methodReporter) isMethodOrServiceDeprecated(md protoreflect.MethodDescriptor) bool {
+func isMethodOrServiceDeprecated(md protoreflect.MethodDescriptor) bool {
 	if isMethodDeprecated(md) {
 		return true
 	}
@@ -0,0 +1,272 @@
+package apideprecation
+
+import (
+	"slices"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// FieldMigrateFunc migrates the value of the deprecated field from of msg into
+// its replacement field to, which is unset. It reports whether msg was changed.
+type FieldMigrateFunc func(msg protoreflect.Message, from, to protoreflect.FieldDescriptor) bool
+
+// MigrationOption configures the request migration enabled by WithRequestMigration.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+type MigrationOption func(*migrationConfig)
+
+type migrationConfig struct {
+	mappings map[protoreflect.FullName]fieldMapping // by deprecated field
+}
+
+type fieldMapping struct {
+	to      protoreflect.FullName
+	migrate FieldMigrateFunc
+}
+
+// MigrateField maps the deprecated field from to its replacement field to of
+// the same message, by full names. If migrate is nil, the value is copied, see
+// CopyField. It takes precedence over the replacement of DeprecationDetails.
+func MigrateField(from, to protoreflect.FullName, migrate FieldMigrateFunc) MigrationOption {
+	return func(c *migrationConfig) {
+		c.mappings[from] = fieldMapping{to: to, migrate: migrate}
+	}
+}
+
+// WithRequestMigration rewrites requests before they reach the handler: the
+// value of every set deprecated field is migrated into its replacement field if
+// the replacement is unset, so handlers only need to read the replacement.
+// Deprecated fields are left unchanged, and their usage is recorded as usual.
+//
+// Replacements are mapped by MigrateField or by the replacement of the field's
+// DeprecationDetails, if it is a field of the same message. Fields mapped to a
+// member of their own oneof are not migrated, as setting the replacement would
+// clear them. Migrated fields are counted in FieldMigratedMetricName.
+//
+// Requests are migrated by the server interceptors and ConnectInterceptor on
+// handlers, but not by StatsHandler and HTTPMiddleware.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithRequestMigration(opts ...MigrationOption) Option {
+	return func(c *config) {
+		cfg := &migrationConfig{mappings: map[protoreflect.FullName]fieldMapping{}}
+		for _, opt := range opts {
+			opt(cfg)
+		}
+		c.migration = cfg
+	}
+}
+
+// CopyField is the default FieldMigrateFunc. It copies the value of from into
+// to if both fields have the same kind, message or enum type, and cardinality,
+// or appends it to to if to is the repeated version of a singular from.
+// Messages, lists, and maps are deep-copied.
+func CopyField(msg protoreflect.Message, from, to protoreflect.FieldDescriptor) bool {
+	if !sameFieldType(from, to) {
+		return false
+	}
+	v := msg.Get(from)
+	switch {
+	case from.IsList() && to.IsList():
+		dst := msg.Mutable(to).List()
+		src := v.List()
+		for i := range src.Len() {
+			dst.Append(cloneValue(src.Get(i)))
+		}
+	case from.IsMap() && to.IsMap():
+		if !sameFieldType(from.MapValue(), to.MapValue()) {
+			return false
+		}
+		dst := msg.Mutable(to).Map()
+		v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			dst.Set(k, cloneValue(v))
+			return true
+		})
+	case from.Cardinality() != protoreflect.Repeated && to.IsList():
+		msg.Mutable(to).List().Append(cloneValue(v))
+	case from.Cardinality() != protoreflect.Repeated && to.Cardinality() != protoreflect.Repeated:
+		msg.Set(to, cloneValue(v))
+	default:
+		return false
+	}
+	return true
+}
+
+// sameFieldType reports whether the values of the fields have the same type,
+// regardless of their cardinality. The key kinds of maps are compared.
+func sameFieldType(a, b protoreflect.FieldDescriptor) bool {
+	if a.IsMap() != b.IsMap() {
+		return false
+	}
+	if a.IsMap() {
+		return a.MapKey().Kind() == b.MapKey().Kind()
+	}
+	if a.Kind() != b.Kind() {
+		return false
+	}
+	switch a.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return a.Message().FullName() == b.Message().FullName()
+	case protoreflect.EnumKind:
+		return a.Enum().FullName() == b.Enum().FullName()
+	default:
+		return true
+	}
+}
+
+func cloneValue(v protoreflect.Value) protoreflect.Value {
+	if msg, ok := v.Interface().(protoreflect.Message); ok {
+		return protoreflect.ValueOfMessage(proto.Clone(msg.Interface()).ProtoReflect())
+	}
+	return v
+}
+
+// migrationPlan lists the field migrations of a message type, and its fields
+// whose message types have migrations.
+type migrationPlan struct {
+	rules    []migrationRule
+	children []protoreflect.FieldDescriptor
+}
+
+type migrationRule struct {
+	from, to protoreflect.FieldDescriptor
+	migrate  FieldMigrateFunc
+}
+
+func (p *migrationPlan) isEmpty() bool {
+	return len(p.rules) == 0 && len(p.children) == 0
+}
+
+// migrate migrates msg and its nested messages. It calls onMigrated for every
+// migrated field. Plans are cached in plans by descriptor, see reporters.
+func (c *migrationConfig) migrate(plans *sync.Map, msg protoreflect.Message, onMigrated func(from, to protoreflect.FieldDescriptor)) {
+	plan := c.plan(plans, msg.Descriptor())
+	for _, rule := range plan.rules {
+		if msg.Has(rule.from) && !msg.Has(rule.to) && rule.migrate(msg, rule.from, rule.to) {
+			onMigrated(rule.from, rule.to)
+		}
+	}
+	for _, fd := range plan.children {
+		if !msg.Has(fd) {
+			continue
+		}
+		switch v := msg.Get(fd); {
+		case fd.IsList():
+			list := v.List()
+			for i := range list.Len() {
+				c.migrate(plans, list.Get(i).Message(), onMigrated)
+			}
+		case fd.IsMap():
+			v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+				c.migrate(plans, v.Message(), onMigrated)
+				return true
+			})
+		default:
+			c.migrate(plans, v.Message(), onMigrated)
+		}
+	}
+}
+
+// plan returns the plan of md, building and caching it and the plans of its
+// nested message types in plans if needed.
+func (c *migrationConfig) plan(plans *sync.Map, md protoreflect.MessageDescriptor) *migrationPlan {
+	if v, ok := plans.Load(md); ok {
+		return v.(*migrationPlan)
+	}
+	built := map[protoreflect.MessageDescriptor]*migrationPlan{}
+	c.buildPlan(plans, md, built)
+	pruneMigrationPlans(built)
+	for md, plan := range built {
+		plans.LoadOrStore(md, plan)
+	}
+	v, _ := plans.Load(md)
+	return v.(*migrationPlan)
+}
+
+// buildPlan builds the plans of md and of its nested message types that are not
+// cached in plans into built. Fields of message types in built are always
+// added as children, as their plans may be recursive, and pruned afterward if
+// they have no migrations, see pruneMigrationPlans.
+func (c *migrationConfig) buildPlan(plans *sync.Map, md protoreflect.MessageDescriptor, built map[protoreflect.MessageDescriptor]*migrationPlan) {
+	plan := &migrationPlan{}
+	built[md] = plan
+	fields := md.Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if rule, ok := c.rule(md, fd); ok {
+			plan.rules = append(plan.rules, rule)
+		}
+
+		child := childMessage(fd)
+		if child == nil {
+			continue
+		}
+		if v, ok := plans.Load(child); ok {
+			if !v.(*migrationPlan).isEmpty() {
+				plan.children = append(plan.children, fd)
+			}
+			continue
+		}
+		if _, ok := built[child]; !ok {
+			c.buildPlan(plans, child, built)
+		}
+		plan.children = append(plan.children, fd)
+	}
+}
+
+// pruneMigrationPlans removes the children of the built plans whose message
+// types have no migrations, directly or through their own children. Plans with
+// migrations are found up to a fixpoint, as message types may be recursive.
+func pruneMigrationPlans(built map[protoreflect.MessageDescriptor]*migrationPlan) {
+	live := map[*migrationPlan]bool{}
+	isLive := func(fd protoreflect.FieldDescriptor) bool {
+		plan, ok := built[childMessage(fd)]
+		return !ok || live[plan] // plans that are not built are cached and not empty
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, plan := range built {
+			if !live[plan] && (len(plan.rules) != 0 || slices.ContainsFunc(plan.children, isLive)) {
+				live[plan] = true
+				changed = true
+			}
+		}
+	}
+	for _, plan := range built {
+		plan.children = slices.DeleteFunc(plan.children, func(fd protoreflect.FieldDescriptor) bool { return !isLive(fd) })
+	}
+}
+
+// childMessage returns the message type of the field, or of its map values.
+func childMessage(fd protoreflect.FieldDescriptor) protoreflect.MessageDescriptor {
+	if fd.IsMap() {
+		return fd.MapValue().Message()
+	}
+	return fd.Message()
+}
+
+// rule returns the migration of the deprecated field fd of md, if mapped to a
+// field of md.
+func (c *migrationConfig) rule(md protoreflect.MessageDescriptor, fd protoreflect.FieldDescriptor) (migrationRule, bool) {
+	if !isFieldDeprecated(fd) {
+		return migrationRule{}, false
+	}
+	mapping, ok := c.mappings[fd.FullName()]
+	if !ok {
+		mapping.to = protoreflect.FullName(DeprecationDetails(fd).GetReplacement())
+	}
+	if mapping.to.Parent() != md.FullName() {
+		return migrationRule{}, false
+	}
+	to := md.Fields().ByName(mapping.to.Name())
+	if to == nil || to == fd {
+		return migrationRule{}, false
+	}
+	if od := fd.ContainingOneof(); od != nil && od == to.ContainingOneof() {
+		return migrationRule{}, false // setting to would clear fd
+	}
+	if mapping.migrate == nil {
+		mapping.migrate = CopyField
+	}
+	return migrationRule{from: fd, to: to, migrate: mapping.migrate}, true
+}
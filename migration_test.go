@@ -0,0 +1,107 @@
+package apideprecation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestWithRequestMigration(t *testing.T) {
+	upper := func(msg protoreflect.Message, from, to protoreflect.FieldDescriptor) bool {
+		msg.Set(to, protoreflect.ValueOfString(strings.ToUpper(msg.Get(from).String())))
+		return true
+	}
+	metrics := NewMetrics(WithRequestMigration(
+		MigrateField("testdata.Contact.name", "testdata.Contact.display_name", upper),
+	))
+
+	req := &pb.Contact{
+		Mail:  "a@example.com",
+		Phone: "+1",
+		Name:  "a",
+		Children: []*pb.Contact{
+			{Email: "b@example.com", Mail: "old-b@example.com"},
+			{Mail: "c@example.com"},
+		},
+	}
+	var got proto.Message
+	_, _ = metrics.UnaryServerInterceptor()(
+		context.Background(), req,
+		&grpc.UnaryServerInfo{FullMethod: "/testdata.ContactService/Update"},
+		func(_ context.Context, req any) (any, error) { got = req.(proto.Message); return nil, nil },
+	)
+
+	want := &pb.Contact{
+		Email:       "a@example.com",
+		Phones:      []string{"+1"},
+		DisplayName: "A",
+		Mail:        "a@example.com",
+		Phone:       "+1",
+		Name:        "a",
+		Children: []*pb.Contact{
+			{Email: "b@example.com", Mail: "old-b@example.com"},
+			{Email: "c@example.com", Mail: "c@example.com"},
+		},
+	}
+	assert.True(t, proto.Equal(want, got), "got %v", got)
+
+	c := metrics.fieldMigrated.WithLabelValues("unary", "testdata.ContactService", "Update", "testdata.Contact.mail", "testdata.Contact.email")
+	assert.Equal(t, float64(2), testutil.ToFloat64(c))
+	c = metrics.fieldMigrated.WithLabelValues("unary", "testdata.ContactService", "Update", "testdata.Contact.phone", "testdata.Contact.phones")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c))
+	c = metrics.fieldMigrated.WithLabelValues("unary", "testdata.ContactService", "Update", "testdata.Contact.name", "testdata.Contact.display_name")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c))
+}
+
+func TestCopyField(t *testing.T) {
+	fields := (&pb.Resource{}).ProtoReflect().Descriptor().Fields()
+	msg := (&pb.Resource{Title: "t", Children: []*pb.Resource{{Name: "c"}}}).ProtoReflect()
+
+	assert.True(t, CopyField(msg, fields.ByName("title"), fields.ByName("display_name")))
+	assert.Equal(t, "t", msg.Interface().(*pb.Resource).GetDisplayName())
+	assert.False(t, CopyField(msg, fields.ByName("title"), fields.ByName("state")), "different kinds")
+	assert.False(t, CopyField(msg, fields.ByName("children"), fields.ByName("labels")), "different message types")
+}
+
+func TestWithRequestMigration_sameOneof(t *testing.T) {
+	metrics := NewMetrics(WithRequestMigration(
+		MigrateField("testdata.Owner.login", "testdata.Owner.email", CopyField),
+	))
+
+	req := &pb.Owner{Id: &pb.Owner_Login{Login: "a"}}
+	_, _ = metrics.UnaryServerInterceptor()(
+		context.Background(), req,
+		&grpc.UnaryServerInfo{FullMethod: "/testdata.ContactService/Update"},
+		func(context.Context, any) (any, error) { return nil, nil },
+	)
+	assert.Equal(t, "a", req.GetLogin())
+}
+
+func TestWithRequestMigration_plans(t *testing.T) {
+	metrics := NewMetrics(WithRequestMigration())
+	plans := &metrics.reporters.Load().migrations
+
+	plan := metrics.cfg.migration.plan(plans, (&structpb.Struct{}).ProtoReflect().Descriptor())
+	assert.True(t, plan.isEmpty(), "recursive types without migrations")
+	plan = metrics.cfg.migration.plan(plans, (&structpb.ListValue{}).ProtoReflect().Descriptor())
+	assert.True(t, plan.isEmpty())
+
+	plan = metrics.cfg.migration.plan(plans, (&pb.Contact{}).ProtoReflect().Descriptor())
+	assert.False(t, plan.isEmpty())
+	if assert.Len(t, plan.children, 1) {
+		assert.Equal(t, protoreflect.Name("children"), plan.children[0].Name())
+	}
+
+	metrics.Reload(nil)
+	_, ok := metrics.reporters.Load().migrations.Load((&pb.Contact{}).ProtoReflect().Descriptor())
+	assert.False(t, ok, "plans are dropped on reload")
+}
@@ -0,0 +1,97 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	apideprecation "github.com/belo4ya/grpc-api-deprecation"
+)
+
+type dashboardPanel struct {
+	title, by, legend, metric string
+}
+
+// GrafanaDashboard generates a Grafana dashboard JSON model with usage rate
+// panels for deprecated methods, fields, and enum values, and a text panel
+// listing the deprecated elements with their effective dates and descriptions.
+func GrafanaDashboard(cfg Config) ([]byte, error) {
+	cfg = cfg.withDefaults()
+
+	datasource := map[string]any{"type": "prometheus", "uid": "${datasource}"}
+	usagePanels := []dashboardPanel{{
+		title:  "Deprecated method calls",
+		by:     "grpc_service, grpc_method",
+		legend: "{{grpc_service}}/{{grpc_method}}",
+		metric: apideprecation.MethodUsedMetricName,
+	}, {
+		title:  "Deprecated field usage",
+		by:     "grpc_service, grpc_method, field",
+		legend: "{{grpc_service}}/{{grpc_method}} {{field}}",
+		metric: apideprecation.FieldUsedMetricName,
+	}, {
+		title:  "Deprecated enum value usage",
+		by:     "grpc_service, grpc_method, field, enum_value",
+		legend: "{{grpc_service}}/{{grpc_method}} {{field}}={{enum_value}}",
+		metric: apideprecation.EnumUsedMetricName,
+	}}
+
+	panels := make([]map[string]any, 0, len(usagePanels)+1)
+	for i, p := range usagePanels {
+		panels = append(panels, map[string]any{
+			"id":         i + 1,
+			"type":       "timeseries",
+			"title":      p.title,
+			"datasource": datasource,
+			"gridPos":    map[string]int{"h": 8, "w": 24, "x": 0, "y": i * 8},
+			"fieldConfig": map[string]any{
+				"defaults":  map[string]any{"unit": "reqps"},
+				"overrides": []any{},
+			},
+			"targets": []map[string]any{{
+				"refId":        "A",
+				"datasource":   datasource,
+				"expr":         fmt.Sprintf("sum by (%s) (rate(%s[$__rate_interval]))", p.by, cfg.metricName(p.metric)),
+				"legendFormat": p.legend,
+			}},
+		})
+	}
+	panels = append(panels, map[string]any{
+		"id":      len(usagePanels) + 1,
+		"type":    "text",
+		"title":   "Deprecated elements",
+		"gridPos": map[string]int{"h": 12, "w": 24, "x": 0, "y": len(usagePanels) * 8},
+		"options": map[string]any{"mode": "markdown", "content": inventoryMarkdown(collectElements(cfg.Files))},
+	})
+
+	return json.MarshalIndent(map[string]any{
+		"title":         cfg.Name,
+		"uid":           cfg.Name,
+		"tags":          []string{"grpc", "deprecation"},
+		"schemaVersion": 39,
+		"editable":      true,
+		"time":          map[string]string{"from": "now-7d", "to": "now"},
+		"templating": map[string]any{"list": []map[string]any{{
+			"name":  "datasource",
+			"label": "Data source",
+			"type":  "datasource",
+			"query": "prometheus",
+		}}},
+		"panels": panels,
+	}, "", "  ")
+}
+
+func inventoryMarkdown(elements []*element) string {
+	var sb strings.Builder
+	sb.WriteString("| Kind | Element | Effective at | Description |\n|---|---|---|---|\n")
+	for _, e := range elements {
+		effectiveAt := "—"
+		if !e.effectiveAt.IsZero() {
+			effectiveAt = e.effectiveAt.Format(time.DateOnly)
+		}
+		description := strings.ReplaceAll(e.description, "|", `\|`)
+		fmt.Fprintf(&sb, "| %s | `%s` | %s | %s |\n", kindTitle(e.Kind), e.Name, effectiveAt, description)
+	}
+	return sb.String()
+}
@@ -0,0 +1,92 @@
+// Package monitoring generates Prometheus alerting rules and Grafana dashboards
+// for deprecated gRPC API elements. Queries are built from protobuf
+// descriptors and (deprecation.*_deprecation_details) annotations and match
+// the metric names and labels produced by apideprecation.Metrics.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+package monitoring
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	apideprecation "github.com/belo4ya/grpc-api-deprecation"
+)
+
+// Config configures rule and dashboard generation.
+type Config struct {
+	// Name is used as the PrometheusRule name, the rule group name, and the
+	// dashboard title. Defaults to "grpc-api-deprecation".
+	Name string
+	// Files provides the descriptors. Defaults to protoregistry.GlobalFiles.
+	Files *protoregistry.Files
+	// Namespace and Subsystem must match the apideprecation.WithNamespace and
+	// apideprecation.WithSubsystem counter options.
+	Namespace string
+	Subsystem string
+	// WarnBefore is how long before effective_at usage starts to alert. Defaults to 14 days.
+	WarnBefore time.Duration
+	// Window is the range over which usage is counted in alerts. Defaults to 1h.
+	Window time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.Name == "" {
+		c.Name = "grpc-api-deprecation"
+	}
+	if c.Files == nil {
+		c.Files = protoregistry.GlobalFiles
+	}
+	if c.WarnBefore == 0 {
+		c.WarnBefore = 14 * 24 * time.Hour
+	}
+	if c.Window == 0 {
+		c.Window = time.Hour
+	}
+	return c
+}
+
+func (c Config) metricName(name string) string {
+	return prometheus.BuildFQName(c.Namespace, c.Subsystem, name)
+}
+
+// element groups all sites of a deprecated element.
+type element struct {
+	apideprecation.Element
+	effectiveAt time.Time // zero if not set or invalid
+	description string
+	replacement string
+	docsURL     string
+	owner       string
+	ticket      string
+	sites       []apideprecation.Site
+}
+
+func collectElements(files *protoregistry.Files) []*element {
+	byElement := map[apideprecation.Element]*element{}
+	var elements []*element
+	for _, site := range apideprecation.Sites(files) {
+		e, ok := byElement[site.Element]
+		if !ok {
+			e = &element{Element: site.Element}
+			if details := apideprecation.DeprecationDetails(site.Descriptor); details != nil {
+				e.effectiveAt, _ = time.Parse(time.DateOnly, details.GetEffectiveAt())
+				e.description = details.GetDescription()
+				e.replacement = details.GetReplacement()
+				e.docsURL = details.GetDocsUrl()
+				e.owner = details.GetOwner()
+				e.ticket = details.GetTicket()
+			}
+			byElement[site.Element] = e
+			elements = append(elements, e)
+		}
+		e.sites = append(e.sites, site)
+	}
+	slices.SortFunc(elements, func(a, b *element) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Name, b.Name))
+	})
+	return elements
+}
@@ -0,0 +1,87 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	apideprecation "github.com/belo4ya/grpc-api-deprecation"
+	_ "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestPrometheusRule(t *testing.T) {
+	out, err := PrometheusRule(Config{Namespace: "ns", Subsystem: "sub"})
+	require.NoError(t, err)
+
+	var manifest prometheusRule
+	require.NoError(t, yaml.Unmarshal(out, &manifest))
+	assert.Equal(t, "PrometheusRule", manifest.Kind)
+	require.Len(t, manifest.Spec.Groups, 1)
+
+	rules := map[string]rule{}
+	for _, r := range manifest.Spec.Groups[0].Rules {
+		rules[r.Alert+"/"+r.Labels["deprecated_element"]] = r
+	}
+
+	// 2025-06-01 minus 14 days and 2025-06-01 in unix seconds.
+	r := rules["DeprecatedAPIUsedBeforeEffectiveDate/testdata.ResourceService.GetResourceLegacy"]
+	assert.Equal(t, `(sum by (grpc_service, grpc_method) (increase(ns_sub_grpc_deprecated_method_used_total{grpc_service="testdata.ResourceService",grpc_method="GetResourceLegacy"}[1h]))) > 0 and on() (vector(time()) >= 1747526400 < 1748736000)`, r.Expr)
+	assert.Equal(t, "warning", r.Labels["severity"])
+	assert.Equal(t, "Use GetResource instead.", r.Annotations["description"])
+
+	r = rules["DeprecatedAPIUsedPastEffectiveDate/testdata.Resource.title"]
+	assert.Equal(t, "critical", r.Labels["severity"])
+	assert.Equal(t, `(sum by (grpc_service, grpc_method, field) (`+
+		`increase(ns_sub_grpc_deprecated_field_used_total{grpc_service="testdata.ResourceService",grpc_method="UpdateResource",field=~"resource\\.children\\[\\]\\.title|resource\\.title"}[1h]) or `+
+		`increase(ns_sub_grpc_deprecated_field_used_total{grpc_service="testdata.ResourceService",grpc_method="WatchResources",field=~"children\\[\\]\\.title|title"}[1h])`+
+		`)) > 0 and on() (vector(time()) >= 1740787200)`, r.Expr)
+
+	r = rules["DeprecatedAPIUsedPastEffectiveDate/testdata.STATE_LEGACY"]
+	assert.Contains(t, r.Expr, `ns_sub_grpc_deprecated_enum_used_total{grpc_service="testdata.ResourceService",grpc_method="WatchResources",field=~"children\\[\\]\\.state|children\\[\\]\\.states|state|states",enum_value="STATE_LEGACY"}`)
+
+	r = rules["DeprecatedAPIUsedPastEffectiveDate/testdata.LegacyResourceService.GetResource"]
+	assert.Equal(t, "2025-01-01", r.Annotations["effective_at"])
+	assert.Equal(t, "team-resources", r.Labels["owner"])
+	assert.Equal(t, "API-42", r.Labels["ticket"])
+	assert.Equal(t, "testdata.ResourceService", r.Annotations["replacement"])
+	assert.Equal(t, "https://example.com/docs/legacy-resource-service", r.Annotations["runbook_url"])
+	assert.NotContains(t, rules["DeprecatedAPIUsedPastEffectiveDate/testdata.Resource.title"].Labels, "owner")
+
+	// Usage of deprecated message types cannot be selected by element.
+	_, ok := usageExpr(Config{}.withDefaults(), &element{
+		Element: apideprecation.Element{Kind: apideprecation.ElementMessage, Name: "testdata.LegacyLabels"},
+		sites: []apideprecation.Site{{
+			Service: "testdata.ResourceService",
+			Method:  "UpdateResource",
+			Element: apideprecation.Element{Kind: apideprecation.ElementMessage, Name: "testdata.LegacyLabels"},
+			Field:   "resource.labels",
+		}},
+	})
+	assert.False(t, ok)
+}
+
+func TestGrafanaDashboard(t *testing.T) {
+	out, err := GrafanaDashboard(Config{Name: "deprecations", Namespace: "ns"})
+	require.NoError(t, err)
+
+	var dashboard struct {
+		Title  string `json:"title"`
+		Panels []struct {
+			Title   string `json:"title"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+			Options struct {
+				Content string `json:"content"`
+			} `json:"options"`
+		} `json:"panels"`
+	}
+	require.NoError(t, json.Unmarshal(out, &dashboard))
+	assert.Equal(t, "deprecations", dashboard.Title)
+	require.Len(t, dashboard.Panels, 4)
+	assert.Equal(t, "sum by (grpc_service, grpc_method, field) (rate(ns_grpc_deprecated_field_used_total[$__rate_interval]))", dashboard.Panels[1].Targets[0].Expr)
+	assert.Contains(t, dashboard.Panels[3].Options.Content, "| field | `testdata.Resource.title` | 2025-03-01 | Use display_name instead. |")
+}
@@ -0,0 +1,184 @@
+package monitoring
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
+
+	apideprecation "github.com/belo4ya/grpc-api-deprecation"
+)
+
+type prometheusRule struct {
+	APIVersion string             `yaml:"apiVersion"`
+	Kind       string             `yaml:"kind"`
+	Metadata   map[string]string  `yaml:"metadata"`
+	Spec       prometheusRuleSpec `yaml:"spec"`
+}
+
+type prometheusRuleSpec struct {
+	Groups []ruleGroup `yaml:"groups"`
+}
+
+type ruleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []rule `yaml:"rules"`
+}
+
+type rule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+// PrometheusRule generates a PrometheusRule (monitoring.coreos.com/v1) manifest
+// with two alerts per deprecated element that has an effective_at date:
+//   - DeprecatedAPIUsedBeforeEffectiveDate (severity=warning) fires when the
+//     element is still used within Config.WarnBefore of its effective date;
+//   - DeprecatedAPIUsedPastEffectiveDate (severity=critical) fires when the
+//     element is used after its effective date.
+//
+// Alerts are labeled with the owner and ticket of the element's
+// DeprecationDetails, if set, to route them to the owning team, and annotated
+// with its replacement and documentation URL (runbook_url).
+//
+// Usage of fields and enum values is matched by the field paths listed by
+// apideprecation.Sites, so it is missed for deeper recursive paths, extension
+// fields, fields referenced by FieldMasks, and counters of Metrics configured
+// with WithFieldPathStyle or WithFieldPathKeys. See apideprecation.Sites.
+func PrometheusRule(cfg Config) ([]byte, error) {
+	cfg = cfg.withDefaults()
+
+	var rules []rule
+	for _, e := range collectElements(cfg.Files) {
+		if e.effectiveAt.IsZero() {
+			continue
+		}
+		usage, ok := usageExpr(cfg, e)
+		if !ok {
+			continue
+		}
+		effectiveAt := e.effectiveAt.Unix()
+		warnAt := e.effectiveAt.Add(-cfg.WarnBefore).Unix()
+		labels := func(severity string) map[string]string {
+			return withOptional(map[string]string{
+				"severity":           severity,
+				"deprecated_kind":    string(e.Kind),
+				"deprecated_element": string(e.Name),
+			}, "owner", e.owner, "ticket", e.ticket)
+		}
+		annotations := func(summary string) map[string]string {
+			return withOptional(map[string]string{
+				"summary":      summary,
+				"description":  e.description,
+				"effective_at": e.effectiveAt.Format(time.DateOnly),
+			}, "replacement", e.replacement, "runbook_url", e.docsURL)
+		}
+
+		rules = append(rules, rule{
+			Alert:  "DeprecatedAPIUsedBeforeEffectiveDate",
+			Expr:   fmt.Sprintf("(%s) > 0 and on() (vector(time()) >= %d < %d)", usage, warnAt, effectiveAt),
+			Labels: labels("warning"),
+			Annotations: annotations(fmt.Sprintf(
+				"Deprecated %s %s is still used and stops working on %s.",
+				kindTitle(e.Kind), e.Name, e.effectiveAt.Format(time.DateOnly))),
+		}, rule{
+			Alert:  "DeprecatedAPIUsedPastEffectiveDate",
+			Expr:   fmt.Sprintf("(%s) > 0 and on() (vector(time()) >= %d)", usage, effectiveAt),
+			Labels: labels("critical"),
+			Annotations: annotations(fmt.Sprintf(
+				"Deprecated %s %s is used after its effective date %s.",
+				kindTitle(e.Kind), e.Name, e.effectiveAt.Format(time.DateOnly))),
+		})
+	}
+
+	return yaml.Marshal(prometheusRule{
+		APIVersion: "monitoring.coreos.com/v1",
+		Kind:       "PrometheusRule",
+		Metadata:   map[string]string{"name": cfg.Name},
+		Spec:       prometheusRuleSpec{Groups: []ruleGroup{{Name: cfg.Name, Rules: rules}}},
+	})
+}
+
+// withOptional adds the non-empty values of key-value pairs kvs to m.
+func withOptional(m map[string]string, kvs ...string) map[string]string {
+	for i := 0; i+1 < len(kvs); i += 2 {
+		if kvs[i+1] != "" {
+			m[kvs[i]] = kvs[i+1]
+		}
+	}
+	return m
+}
+
+// usageExpr sums the usage of the element over all of its sites. Sites of the
+// same method are matched by a single selector. It reports false if the usage
+// of the element kind cannot be selected.
+func usageExpr(cfg Config, e *element) (string, bool) {
+	by := "grpc_service, grpc_method"
+	if e.Kind != apideprecation.ElementMethod {
+		by += ", field"
+	}
+	window := model.Duration(cfg.Window).String()
+
+	var series []string
+	for i := 0; i < len(e.sites); {
+		j := i + 1
+		for j < len(e.sites) && e.sites[j].Service == e.sites[i].Service && e.sites[j].Method == e.sites[i].Method {
+			j++
+		}
+		sel, ok := selector(cfg, e.sites[i:j])
+		if !ok {
+			return "", false
+		}
+		series = append(series, fmt.Sprintf("increase(%s[%s])", sel, window))
+		i = j
+	}
+	return fmt.Sprintf("sum by (%s) (%s)", by, strings.Join(series, " or ")), true
+}
+
+// selector matches the given sites of a single method. It reports false for
+// deprecated message types, as their usage is counted by the field counter
+// under the paths of the fields of that type, which are not listed by
+// apideprecation.Sites.
+func selector(cfg Config, sites []apideprecation.Site) (string, bool) {
+	site := sites[0]
+	matchers := []string{
+		"grpc_service=" + strconv.Quote(site.Service),
+		"grpc_method=" + strconv.Quote(site.Method),
+	}
+	var name string
+	switch site.Element.Kind {
+	case apideprecation.ElementMethod:
+		name = cfg.metricName(apideprecation.MethodUsedMetricName)
+	case apideprecation.ElementField:
+		name = cfg.metricName(apideprecation.FieldUsedMetricName)
+		matchers = append(matchers, fieldMatcher(sites))
+	case apideprecation.ElementEnumValue:
+		name = cfg.metricName(apideprecation.EnumUsedMetricName)
+		enumValue := string(site.Element.Name.Name())
+		matchers = append(matchers, fieldMatcher(sites), "enum_value="+strconv.Quote(enumValue))
+	case apideprecation.ElementMessage:
+		return "", false
+	}
+	return name + "{" + strings.Join(matchers, ",") + "}", true
+}
+
+func fieldMatcher(sites []apideprecation.Site) string {
+	if len(sites) == 1 {
+		return "field=" + strconv.Quote(sites[0].Field)
+	}
+	fields := make([]string, 0, len(sites))
+	for _, site := range sites {
+		fields = append(fields, regexp.QuoteMeta(site.Field))
+	}
+	return "field=~" + strconv.Quote(strings.Join(fields, "|"))
+}
+
+func kindTitle(kind apideprecation.ElementKind) string {
+	return strings.ReplaceAll(string(kind), "_", " ")
+}
@@ -0,0 +1,85 @@
+package apideprecation
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	deprecation "github.com/belo4ya/grpc-api-deprecation/annotations"
+)
+
+// OneofLabel returns a "oneof" label with the name of the oneof containing a
+// deprecated field, or an empty value if the field is not a member of a oneof.
+// Use it with WithExtraLabels for fields.
+func OneofLabel() Label {
+	return Label{
+		Name: "oneof",
+		Value: func(_ context.Context, _ proto.Message, _ CallMeta, _ protoreflect.MethodDescriptor, fd protoreflect.FieldDescriptor) string {
+			if od := containingOneof(fd); od != nil {
+				return string(od.Name())
+			}
+			return ""
+		},
+	}
+}
+
+// OneofAlternativesLabel returns a "oneof_alternatives" label with the
+// comma-separated names of the non-deprecated fields of the oneof containing a
+// deprecated field, i.e. the cases clients can migrate to. The value is empty
+// if the field is not a member of a oneof or all its fields are deprecated.
+// Use it with WithExtraLabels for fields.
+func OneofAlternativesLabel() Label {
+	return Label{
+		Name: "oneof_alternatives",
+		Value: func(ctx context.Context, _ proto.Message, _ CallMeta, _ protoreflect.MethodDescriptor, fd protoreflect.FieldDescriptor) string {
+			if od := containingOneof(fd); od != nil {
+				scope, _ := ctx.Value(deprecatedDescriptorKey{}).(labelScope)
+				return oneofAlternatives(scope.reporters, od)
+			}
+			return ""
+		},
+	}
+}
+
+// oneofAlternatives returns the value of OneofAlternativesLabel, cached by the
+// reporters that found the deprecated field, if not nil.
+func oneofAlternatives(reporters *reporters, od protoreflect.OneofDescriptor) string {
+	if reporters != nil {
+		if v, ok := reporters.oneofs.Load(od); ok {
+			return v.(string)
+		}
+	}
+	var names []string
+	fields := od.Fields()
+	for i := range fields.Len() {
+		if fd := fields.Get(i); !isFieldDeprecated(fd) {
+			names = append(names, string(fd.Name()))
+		}
+	}
+	alternatives := strings.Join(names, ",")
+	if reporters != nil {
+		reporters.oneofs.Store(od, alternatives)
+	}
+	return alternatives
+}
+
+// containingOneof returns the real (not synthetic, as for proto3 optional
+// fields) oneof containing fd, or nil.
+func containingOneof(fd protoreflect.FieldDescriptor) protoreflect.OneofDescriptor {
+	if fd == nil {
+		return nil
+	}
+	if od := fd.ContainingOneof(); od != nil && !od.IsSynthetic() {
+		return od
+	}
+	return nil
+}
+
+// isOneofDeprecated reports whether the oneof is marked with
+// `option (deprecation.oneof_deprecated) = true`.
+func isOneofDeprecated(od protoreflect.OneofDescriptor) bool {
+	opts := od.Options()
+	return opts != nil && proto.GetExtension(opts, deprecation.E_OneofDeprecated).(bool)
+}
@@ -0,0 +1,89 @@
+package apideprecation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestOneof(t *testing.T) {
+	type fieldMetric struct {
+		field, presence, oneof, alternatives string
+	}
+
+	tests := []struct {
+		name string
+		req  proto.Message
+		want []fieldMetric
+	}{
+		{
+			name: "deprecated oneof case",
+			req:  &pb.Owner{Id: &pb.Owner_Login{Login: "l"}},
+			want: []fieldMetric{{field: "login", presence: "explicit", oneof: "id", alternatives: "email,user_id"}},
+		},
+		{
+			name: "not deprecated oneof case",
+			req:  &pb.Owner{Id: &pb.Owner_Email{Email: "e"}},
+			want: nil,
+		},
+		{
+			name: "deprecated oneof",
+			req:  &pb.Owner{LegacyId: &pb.Owner_LegacyNumber{LegacyNumber: 1}},
+			want: []fieldMetric{{field: "legacy_number", presence: "explicit", oneof: "legacy_id", alternatives: ""}},
+		},
+		{
+			name: "deprecated field outside oneof",
+			req:  &pb.AllInclusive{ScalarOptionalDeprecated: proto.Int32(1)},
+			want: []fieldMetric{{field: "scalar_optional_deprecated", presence: "explicit", oneof: "", alternatives: ""}},
+		},
+		{
+			name: "synthetic oneof is not deprecated",
+			req:  &pb.Owner{DisplayName: proto.String("d")},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := NewMetrics(WithExtraLabels(LabelSet{Field: []Label{OneofLabel(), OneofAlternativesLabel()}}))
+			_, _ = metrics.UnaryServerInterceptor()(
+				context.Background(), tt.req,
+				&grpc.UnaryServerInfo{FullMethod: "/t.Service/Method"},
+				func(context.Context, any) (any, error) { return nil, nil },
+			)
+
+			assert.Equal(t, len(tt.want), testutil.CollectAndCount(metrics.deprecatedFieldUsed))
+			for _, want := range tt.want {
+				c := metrics.deprecatedFieldUsed.WithLabelValues("unary", "t.Service", "Method", want.field, want.presence, want.oneof, want.alternatives)
+				assert.Equal(t, float64(1), testutil.ToFloat64(c), want)
+			}
+		})
+	}
+}
+
+func TestOneofAlternativesLabel_cache(t *testing.T) {
+	metrics := NewMetrics(WithExtraLabels(LabelSet{Field: []Label{OneofAlternativesLabel()}}))
+	call := func() {
+		_, _ = metrics.UnaryServerInterceptor()(
+			context.Background(), &pb.Owner{Id: &pb.Owner_Login{Login: "l"}},
+			&grpc.UnaryServerInfo{FullMethod: "/t.Service/Method"},
+			func(context.Context, any) (any, error) { return nil, nil },
+		)
+	}
+	od := (&pb.Owner{}).ProtoReflect().Descriptor().Oneofs().ByName("id")
+
+	call()
+	v, ok := metrics.reporters.Load().oneofs.Load(od)
+	assert.True(t, ok)
+	assert.Equal(t, "email,user_id", v)
+
+	metrics.Reload(protoregistry.GlobalFiles)
+	_, ok = metrics.reporters.Load().oneofs.Load(od)
+	assert.False(t, ok, "dropped on Reload")
+}
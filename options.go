@@ -8,6 +8,15 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Values of the "via" label, added by WithFieldMasks to the field counter and
+// by WithImplicitEnumDefaults to the enum counter.
+const (
+	viaValue           = "value"
+	viaFieldMask       = "field_mask"
+	viaImplicitDefault = "implicit_default"
 )
 
 type config struct {
@@ -15,6 +24,20 @@ type config struct {
 	exemplar    ExemplarSet
 	seedDesc    []grpc.ServiceDesc
 	counterOpts counterOptions
+	tracker     *UsageTracker
+	hitters     *HeavyHitters
+	counting    countingConfig
+	warnings    bool
+	fieldMasks  bool
+	fieldPath   fieldPathRenderer
+	enumDefault *enumDefaultPolicy
+	extTypes    *protoregistry.Types
+	files       *protoregistry.Files
+	brownouts   *brownoutConfig
+	exemptions  *exemptionConfig
+	policy      *Policy
+	throttler   *throttler
+	migration   *migrationConfig
 }
 
 // LabelSet defines ordered dynamic labels that are appended to the default metric labels.
@@ -37,7 +60,8 @@ type Label struct {
 // LabelValueFunc extracts a label or exemplar value from the current call
 // context, request message, and resolved descriptors. Implementations should be
 // fast and allocation-conscious. Method or field descriptors may be nil when
-// they do not apply to the current metric.
+// they do not apply to the current metric. The descriptor of the deprecated
+// element itself is available from ctx with DeprecatedDescriptor.
 type LabelValueFunc func(
 	ctx context.Context,
 	msg proto.Message,
@@ -45,6 +69,31 @@ type LabelValueFunc func(
 	md protoreflect.MethodDescriptor, fd protoreflect.FieldDescriptor,
 ) string
 
+// TransportLabel returns a "transport" label with the transport a deprecated
+// usage was observed on (CallMeta.Transport), e.g. "grpc" or "http". Use it
+// with WithExtraLabels to tell apart usage from gRPC and HTTP/JSON clients.
+func TransportLabel() Label {
+	return Label{
+		Name: "transport",
+		Value: func(_ context.Context, _ proto.Message, meta CallMeta, _ protoreflect.MethodDescriptor, _ protoreflect.FieldDescriptor) string {
+			return meta.Transport
+		},
+	}
+}
+
+// SideLabel returns a "side" label with the side a deprecated usage was
+// observed on (CallMeta.Side): "server" for received requests, or "client"
+// for requests sent through ConnectInterceptor or StatsHandler on clients. Use
+// it with WithExtraLabels when the same Metrics observes both sides.
+func SideLabel() Label {
+	return Label{
+		Name: "side",
+		Value: func(_ context.Context, _ proto.Message, meta CallMeta, _ protoreflect.MethodDescriptor, _ protoreflect.FieldDescriptor) string {
+			return meta.Side
+		},
+	}
+}
+
 type Option func(*config)
 
 // WithExtraLabels appends user-defined labels to deprecated method, field, and
@@ -82,6 +131,44 @@ func WithCounterOptions(opts ...CounterOption) Option {
 	}
 }
 
+// WithFiles sets the registry used to resolve RPC methods and prewarm caches.
+// Defaults to protoregistry.GlobalFiles. See also Metrics.Reload.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithFiles(files *protoregistry.Files) Option {
+	return func(c *config) {
+		c.files = files
+	}
+}
+
+// WithExtensionTypes sets the registry of extension types checked for
+// deprecated extension fields and enum values in extendable (proto2 and
+// editions) messages. Extensions are reported with a "[pkg.ext]" field path
+// segment. Defaults to protoregistry.GlobalTypes.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithExtensionTypes(types *protoregistry.Types) Option {
+	return func(c *config) {
+		c.extTypes = types
+	}
+}
+
+// WithUsageTracker records every observed deprecated method, field, and enum
+// value usage in the given UsageTracker in addition to the counters.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithUsageTracker(tracker *UsageTracker) Option {
+	return func(c *config) {
+		c.tracker = tracker
+	}
+}
+
+// WithHeavyHitters records the caller of every observed deprecated method,
+// field, and enum value usage in the given HeavyHitters.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithHeavyHitters(hitters *HeavyHitters) Option {
+	return func(c *config) {
+		c.hitters = hitters
+	}
+}
+
 // CounterOption lets you add options to Counter metrics using With* funcs.
 // Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
 type CounterOption = grpcprom.CounterOption
@@ -0,0 +1,370 @@
+package apideprecation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"gopkg.in/yaml.v3"
+
+	deprecation "github.com/belo4ya/grpc-api-deprecation/annotations"
+)
+
+// Stage is a lifecycle stage of a deprecated element, assigned by a Policy.
+type Stage string
+
+const (
+	// StageNone is reported for elements without a policy rule. WithWarnings
+	// and WithBrownouts apply to them as without a policy.
+	StageNone Stage = "none"
+	// StageObserve records usage only, without warnings.
+	StageObserve Stage = "observe"
+	// StageWarn records usage and sends warnings.
+	StageWarn Stage = "warn"
+	// StageBrownout also rejects a percentage of calls with a BrownoutError.
+	StageBrownout Stage = "brownout"
+	// StageReject rejects all calls with a PolicyError.
+	StageReject Stage = "reject"
+	// StageRemoved rejects all calls with a PolicyError, for elements that are
+	// removed from the API but still registered.
+	StageRemoved Stage = "removed"
+)
+
+// Policy assigns lifecycle stages to deprecated elements, loaded from a YAML
+// or JSON policy file, e.g.
+//
+//	rules:
+//	  - element: pkg.Service.Method   # full name or path.Match pattern, e.g. "pkg.*"
+//	    stage: warn
+//	    from: 2025-01-01              # date or RFC 3339 time, optional
+//	    until: 2025-05-01             # exclusive, optional
+//	  - element: pkg.Service.Method
+//	    stage: brownout
+//	    percent: 10                   # share of rejected calls, defaults to 100
+//	    from: 2025-05-01
+//	environments:
+//	  staging:                        # rules evaluated before the common ones
+//	    - element: pkg.*
+//	      stage: reject
+//
+// The stage of an element is set by the first rule matching its full name, or
+// the full name of the service of a method, whose dates contain the current
+// time. Rules of the environment selected by WithPolicyEnvironment come first.
+// Attach a Policy to Metrics using WithPolicy.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+type Policy struct {
+	path string
+	env  string
+	now  func() time.Time
+	rand func() float64
+
+	compiled atomic.Pointer[compiledPolicy] // swapped by Reload
+}
+
+// PolicyOption configures a Policy.
+type PolicyOption func(*Policy)
+
+// WithPolicyEnvironment selects the environment whose rules override the common rules.
+func WithPolicyEnvironment(env string) PolicyOption {
+	return func(p *Policy) {
+		p.env = env
+	}
+}
+
+// WithPolicyClock sets the clock used to match rule dates. Defaults to time.Now.
+func WithPolicyClock(now func() time.Time) PolicyOption {
+	return func(p *Policy) {
+		p.now = now
+	}
+}
+
+// WithPolicyRand sets the source of random numbers in [0, 1) used by the
+// brownout stage. Defaults to math/rand/v2.Float64.
+func WithPolicyRand(rand func() float64) PolicyOption {
+	return func(p *Policy) {
+		p.rand = rand
+	}
+}
+
+// LoadPolicy loads a policy file. See Policy for its format.
+func LoadPolicy(path string, opts ...PolicyOption) (*Policy, error) {
+	p := newPolicy(opts)
+	p.path = path
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ParsePolicy parses the contents of a policy file. See Policy for its format.
+func ParsePolicy(data []byte, opts ...PolicyOption) (*Policy, error) {
+	p := newPolicy(opts)
+	compiled, err := compilePolicy(data, p.env)
+	if err != nil {
+		return nil, err
+	}
+	p.compiled.Store(compiled)
+	return p, nil
+}
+
+func newPolicy(opts []PolicyOption) *Policy {
+	p := &Policy{now: time.Now, rand: rand.Float64}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Reload reads the policy file again and atomically replaces the rules. On
+// error, the previous rules are kept.
+func (p *Policy) Reload() error {
+	if p.path == "" {
+		return errors.New("policy is not loaded from a file")
+	}
+	state, err := policyFileState(p.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	compiled, err := compilePolicy(data, p.env)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.path, err)
+	}
+	compiled.fileState = state
+	p.compiled.Store(compiled)
+	return nil
+}
+
+// Watch polls the policy file every interval and reloads it whenever its size
+// or modification time changes. Errors are passed to onError, if not nil, and
+// the previous rules are kept. It blocks until ctx is done.
+func (p *Policy) Watch(ctx context.Context, interval time.Duration, onError func(error)) error {
+	if p.path == "" {
+		return errors.New("policy is not loaded from a file")
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			state, err := policyFileState(p.path)
+			if err == nil && state != p.compiled.Load().fileState {
+				err = p.Reload()
+			}
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+func policyFileState(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano()), nil
+}
+
+// Stage returns the current stage of the element described by desc.
+func (p *Policy) Stage(desc protoreflect.Descriptor) Stage {
+	rule := p.rule(desc)
+	if rule == nil {
+		return StageNone
+	}
+	return rule.stage
+}
+
+func (p *Policy) rule(desc protoreflect.Descriptor) *policyRule {
+	now := p.now()
+	for _, rule := range p.compiled.Load().rules(desc) {
+		if rule.active(now) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// rejects reports whether a call using the element is rejected by the rule.
+func (p *Policy) rejects(rule *policyRule) bool {
+	switch rule.stage {
+	case StageBrownout:
+		return rule.percent >= 100 || p.rand()*100 < rule.percent
+	case StageReject, StageRemoved:
+		return true
+	default:
+		return false
+	}
+}
+
+type policyFile struct {
+	Rules        []policyFileRule            `yaml:"rules"`
+	Environments map[string][]policyFileRule `yaml:"environments"`
+}
+
+type policyFileRule struct {
+	Element string   `yaml:"element"`
+	Stage   Stage    `yaml:"stage"`
+	From    string   `yaml:"from"`
+	Until   string   `yaml:"until"`
+	Percent *float64 `yaml:"percent"`
+}
+
+type compiledPolicy struct {
+	rulesList []*policyRule
+	fileState string   // size and modification time of the policy file
+	cache     sync.Map // protoreflect.FullName -> []*policyRule
+}
+
+type policyRule struct {
+	pattern     string
+	stage       Stage
+	from, until time.Time
+	percent     float64
+}
+
+func (r *policyRule) active(now time.Time) bool {
+	return (r.from.IsZero() || !now.Before(r.from)) && (r.until.IsZero() || now.Before(r.until))
+}
+
+func (r *policyRule) matches(name protoreflect.FullName) bool {
+	ok, _ := path.Match(r.pattern, string(name))
+	return ok
+}
+
+func compilePolicy(data []byte, env string) (*compiledPolicy, error) {
+	var file policyFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decode policy: %w", err)
+	}
+
+	compiled := &compiledPolicy{}
+	for _, raw := range append(file.Environments[env], file.Rules...) {
+		rule, err := compilePolicyRule(raw)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", raw.Element, err)
+		}
+		compiled.rulesList = append(compiled.rulesList, rule)
+	}
+	return compiled, nil
+}
+
+func compilePolicyRule(raw policyFileRule) (*policyRule, error) {
+	if raw.Element == "" {
+		return nil, errors.New("element is required")
+	}
+	if _, err := path.Match(raw.Element, ""); err != nil {
+		return nil, err
+	}
+	switch raw.Stage {
+	case StageObserve, StageWarn, StageBrownout, StageReject, StageRemoved:
+	default:
+		return nil, fmt.Errorf("unknown stage %q", raw.Stage)
+	}
+
+	rule := &policyRule{pattern: raw.Element, stage: raw.Stage, percent: 100}
+	if raw.Percent != nil {
+		if *raw.Percent < 0 || *raw.Percent > 100 {
+			return nil, fmt.Errorf("percent %v is out of range [0, 100]", *raw.Percent)
+		}
+		rule.percent = *raw.Percent
+	}
+	var err error
+	if rule.from, err = parsePolicyTime(raw.From); err != nil {
+		return nil, fmt.Errorf("from: %w", err)
+	}
+	if rule.until, err = parsePolicyTime(raw.Until); err != nil {
+		return nil, fmt.Errorf("until: %w", err)
+	}
+	return rule, nil
+}
+
+func parsePolicyTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// rules returns the rules matching the element described by desc, in order.
+func (c *compiledPolicy) rules(desc protoreflect.Descriptor) []*policyRule {
+	if v, ok := c.cache.Load(desc.FullName()); ok {
+		return v.([]*policyRule)
+	}
+	var service protoreflect.FullName
+	if md, ok := desc.(protoreflect.MethodDescriptor); ok {
+		service = md.Parent().FullName()
+	}
+	var rules []*policyRule
+	for _, rule := range c.rulesList {
+		if rule.matches(desc.FullName()) || (service != "" && rule.matches(service)) {
+			rules = append(rules, rule)
+		}
+	}
+	c.cache.Store(desc.FullName(), rules)
+	return rules
+}
+
+// WithPolicy applies the lifecycle stages of policy to deprecated elements:
+// warnings are sent in the warn and brownout stages, calls are rejected in
+// the brownout, reject, and removed stages, and usage is recorded in all of
+// them, with a "stage" label added to all counters after the "exempt" label.
+// Elements without a policy rule are reported with StageNone.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithPolicy(policy *Policy) Option {
+	return func(c *config) {
+		c.policy = policy
+	}
+}
+
+// PolicyError is returned for calls rejected in the reject and removed stages
+// of a Policy.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+type PolicyError struct {
+	// Element is the deprecated element that rejected the call.
+	Element Element
+	// Stage is the stage of the element.
+	Stage   Stage
+	warning string
+	details *deprecation.DeprecationDetails
+}
+
+func (e *PolicyError) Error() string {
+	if e.Stage == StageRemoved {
+		return e.warning + " (removed)"
+	}
+	return e.warning + " (rejected by the deprecation policy)"
+}
+
+// Code returns the gRPC code of the error: Unimplemented for methods, and
+// InvalidArgument for fields and enum values.
+func (e *PolicyError) Code() codes.Code {
+	return rejectionCode(e.Element)
+}
+
+// GRPCStatus implements the interface used by the status package to convert
+// errors to gRPC statuses. Its details are those of BrownoutError.
+func (e *PolicyError) GRPCStatus() *status.Status {
+	reason := "DEPRECATED_API_REJECTED"
+	if e.Stage == StageRemoved {
+		reason = "DEPRECATED_API_REMOVED"
+	}
+	return rejectionStatus(e.Code(), e.Error(), reason, e.Element, e.details)
+}
@@ -0,0 +1,147 @@
+package apideprecation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+const testPolicy = `
+rules:
+  - element: testdata.ResourceService.GetResourceLegacy
+    stage: observe
+    until: 2025-03-01
+  - element: testdata.ResourceService.GetResourceLegacy
+    stage: warn
+    from: 2025-03-01
+    until: 2025-05-01
+  - element: testdata.ResourceService.GetResourceLegacy
+    stage: brownout
+    percent: 50
+    from: 2025-05-01
+    until: 2025-06-01
+  - element: testdata.ResourceService.GetResourceLegacy
+    stage: reject
+    from: 2025-06-01T00:00:00Z
+  - element: testdata.LegacyResourceService
+    stage: removed
+environments:
+  staging:
+    - element: testdata.Resource.*
+      stage: reject
+`
+
+func TestPolicy(t *testing.T) {
+	getResourceLegacy := pb.File_service_proto.Services().ByName("ResourceService").Methods().ByName("GetResourceLegacy")
+	legacyGetResource := pb.File_service_proto.Services().ByName("LegacyResourceService").Methods().ByName("GetResource")
+	title := (&pb.Resource{}).ProtoReflect().Descriptor().Fields().ByName("title")
+
+	var now time.Time
+	policy, err := ParsePolicy([]byte(testPolicy), WithPolicyClock(func() time.Time { return now }))
+	require.NoError(t, err)
+
+	for date, want := range map[string]Stage{
+		"2025-01-01": StageObserve,
+		"2025-03-01": StageWarn,
+		"2025-05-31": StageBrownout,
+		"2025-06-01": StageReject,
+	} {
+		now, _ = time.Parse(time.DateOnly, date)
+		assert.Equal(t, want, policy.Stage(getResourceLegacy), date)
+	}
+	assert.Equal(t, StageRemoved, policy.Stage(legacyGetResource), "service rule")
+	assert.Equal(t, StageNone, policy.Stage(title))
+
+	staging, err := ParsePolicy([]byte(testPolicy), WithPolicyEnvironment("staging"))
+	require.NoError(t, err)
+	assert.Equal(t, StageReject, staging.Stage(title))
+
+	for _, data := range []string{
+		"rules: [{stage: warn}]",
+		"rules: [{element: a, stage: unknown}]",
+		"rules: [{element: a, stage: brownout, percent: 101}]",
+		"rules: [{element: a, stage: warn, from: tomorrow}]",
+		"rules: [{element: '[', stage: warn}]",
+		"rules: {}",
+	} {
+		_, err := ParsePolicy([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestWithPolicy(t *testing.T) {
+	now := time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC)
+	rand := 0.0
+	policy, err := ParsePolicy([]byte(testPolicy),
+		WithPolicyClock(func() time.Time { return now }),
+		WithPolicyRand(func() float64 { return rand }),
+	)
+	require.NoError(t, err)
+	metrics := NewMetrics(WithPolicy(policy))
+
+	observe := func(fullMethod string) (warnings, error) {
+		return metrics.observe(context.Background(), &pb.GetResourceRequest{}, newCallMeta(fullMethod, nil), &callEnforcement{})
+	}
+
+	warns, err := observe("/testdata.ResourceService/GetResourceLegacy")
+	assert.Len(t, warns, 1)
+	var brownoutErr *BrownoutError
+	assert.ErrorAs(t, err, &brownoutErr)
+	rand = 0.5
+	_, err = observe("/testdata.ResourceService/GetResourceLegacy")
+	assert.NoError(t, err)
+
+	_, err = observe("/testdata.LegacyResourceService/GetResource")
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	assert.Contains(t, err.Error(), "(removed)")
+
+	now = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	warns, err = observe("/testdata.ResourceService/GetResourceLegacy")
+	assert.NoError(t, err)
+	assert.Empty(t, warns, "observe stage")
+
+	c := metrics.deprecatedMethodUsed.WithLabelValues("unary", "testdata.ResourceService", "GetResourceLegacy", "brownout")
+	assert.Equal(t, float64(2), testutil.ToFloat64(c))
+	c = metrics.deprecatedMethodUsed.WithLabelValues("unary", "testdata.ResourceService", "GetResourceLegacy", "observe")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c))
+	c = metrics.deprecatedMethodUsed.WithLabelValues("unary", "testdata.LegacyResourceService", "GetResource", "removed")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c))
+	c = metrics.brownoutRejected.WithLabelValues("unary", "testdata.ResourceService", "GetResourceLegacy", "method", "testdata.ResourceService.GetResourceLegacy")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c))
+}
+
+func TestPolicyWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	write := func(stage Stage) {
+		data := []byte(`{"rules": [{"element": "testdata.*", "stage": "` + stage + `"}]}`)
+		require.NoError(t, os.WriteFile(path+".tmp", data, 0o644))
+		require.NoError(t, os.Rename(path+".tmp", path))
+	}
+	write(StageWarn)
+
+	policy, err := LoadPolicy(path)
+	require.NoError(t, err)
+	title := (&pb.Resource{}).ProtoReflect().Descriptor().Fields().ByName("title")
+	assert.Equal(t, StageWarn, policy.Stage(title))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- policy.Watch(ctx, 10*time.Millisecond, nil) }()
+
+	write(StageReject)
+	require.Eventually(t, func() bool { return policy.Stage(title) == StageReject }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
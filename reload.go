@@ -0,0 +1,211 @@
+package apideprecation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// reporters resolve deprecated elements against a registry of descriptors and
+// cache the results. Metrics replaces them as a whole on Reload.
+type reporters struct {
+	method     *methodReporter
+	field      *fieldReporter
+	fieldMask  *fieldMaskReporter
+	brownouts  sync.Map // protoreflect.Descriptor -> []BrownoutWindow, see WithBrownouts
+	oneofs     sync.Map // protoreflect.OneofDescriptor -> string, see OneofAlternativesLabel
+	migrations sync.Map // protoreflect.MessageDescriptor -> *migrationPlan, see WithRequestMigration
+}
+
+func newReporters(
+	cfg *config,
+	files *protoregistry.Files,
+	extTypes *protoregistry.Types,
+	svcSeed []protoreflect.ServiceDescriptor,
+	msgSeed []protoreflect.MessageDescriptor,
+) *reporters {
+	return &reporters{
+		method:    newMethodReporter(files, svcSeed),
+		field:     newFieldReporter(msgSeed, cfg.fieldPath, cfg.enumDefault, extTypes),
+		fieldMask: newFieldMaskReporter(files, cfg.fieldPath),
+	}
+}
+
+// Reload replaces the descriptors used to resolve RPC methods with files and
+// drops all caches of the previous descriptors. Extensions are resolved with
+// dynamic extension types of files instead of WithExtensionTypes. Caches are
+// prewarmed with every service of files before they are swapped in atomically,
+// so in-flight and concurrent calls are never blocked and keep using the
+// previous caches until they finish. Counters are preserved.
+//
+// Use it when descriptors change at runtime, e.g. when they are served by a
+// schema registry, along with dynamicpb messages built from files. Routes of
+// HTTPMiddleware are not reloaded.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func (m *Metrics) Reload(files *protoregistry.Files) {
+	var services []protoreflect.ServiceDescriptor
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		for i := range fd.Services().Len() {
+			services = append(services, fd.Services().Get(i))
+		}
+		return true
+	})
+	svcSeed, msgSeed := prewarmSeed(services)
+	m.reporters.Store(newReporters(m.cfg, files, fileExtensionTypes(files), svcSeed, msgSeed))
+}
+
+// fileExtensionTypes returns the dynamic extension types of files, to resolve
+// extensions of dynamicpb messages built from files. Extensions conflicting
+// with ones registered first are skipped.
+func fileExtensionTypes(files *protoregistry.Files) *protoregistry.Types {
+	types := &protoregistry.Types{}
+	addExtensions := func(xds protoreflect.ExtensionDescriptors) {
+		for i := range xds.Len() {
+			_ = types.RegisterExtension(dynamicpb.NewExtensionType(xds.Get(i)))
+		}
+	}
+	var addMessages func(mds protoreflect.MessageDescriptors)
+	addMessages = func(mds protoreflect.MessageDescriptors) {
+		for i := range mds.Len() {
+			addExtensions(mds.Get(i).Extensions())
+			addMessages(mds.Get(i).Messages())
+		}
+	}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		addExtensions(fd.Extensions())
+		addMessages(fd.Messages())
+		return true
+	})
+	return types
+}
+
+// WatchDescriptorSets loads binary FileDescriptorSets from path, a file or a
+// directory of files, calls Reload with them, and polls path every interval to
+// reload them again whenever a file is added, removed, or modified. It blocks
+// until ctx is done.
+//
+// An error loading the initial descriptors is returned. Later errors, e.g. a
+// partially written file, are passed to onError, if not nil, and the previous
+// descriptors are kept until the next successful load.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func (m *Metrics) WatchDescriptorSets(ctx context.Context, path string, interval time.Duration, onError func(error)) error {
+	state, err := m.reloadDescriptorSets(path, "")
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			newState, err := m.reloadDescriptorSets(path, state)
+			if err != nil {
+				if onError != nil {
+					onError(err)
+				}
+				continue
+			}
+			state = newState
+		}
+	}
+}
+
+// reloadDescriptorSets reloads the descriptor sets at path if their state, the
+// names, sizes, and modification times of the files, differs from prevState.
+func (m *Metrics) reloadDescriptorSets(path, prevState string) (string, error) {
+	paths, err := descriptorSetPaths(path)
+	if err != nil {
+		return "", err
+	}
+	var state string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return "", err
+		}
+		state += fmt.Sprintf("%s:%d:%d;", p, info.Size(), info.ModTime().UnixNano())
+	}
+	if state == prevState {
+		return state, nil
+	}
+
+	files, err := LoadDescriptorSets(paths...)
+	if err != nil {
+		return "", err
+	}
+	m.Reload(files)
+	return state, nil
+}
+
+func descriptorSetPaths(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && !isHiddenFile(entry.Name()) {
+			paths = append(paths, filepath.Join(path, entry.Name()))
+		}
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
+
+// isHiddenFile reports whether name is a dotfile, e.g. a temporary file of an
+// atomic write.
+func isHiddenFile(name string) bool {
+	return name != "" && name[0] == '.'
+}
+
+// LoadDescriptorSets reads binary FileDescriptorSets, e.g. produced by
+// `buf build -o set.binpb` or `protoc --include_imports --descriptor_set_out`,
+// into a single registry. Files present in several sets are taken from the
+// first one.
+func LoadDescriptorSets(paths ...string) (*protoregistry.Files, error) {
+	var merged descriptorpb.FileDescriptorSet
+	seen := map[string]bool{}
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var set descriptorpb.FileDescriptorSet
+		if err := proto.Unmarshal(raw, &set); err != nil {
+			return nil, fmt.Errorf("%s: decode FileDescriptorSet: %w", path, err)
+		}
+		for _, file := range set.GetFile() {
+			if !seen[file.GetName()] {
+				seen[file.GetName()] = true
+				merged.File = append(merged.File, file)
+			}
+		}
+	}
+	files, err := protodesc.NewFiles(&merged)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", paths, err)
+	}
+	return files, nil
+}
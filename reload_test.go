@@ -0,0 +1,169 @@
+package apideprecation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// reloadFileDescriptor returns a file with reload.Service methods Get and
+// Update, taking reload.Request{string a}. If deprecated, Get and the field a
+// are deprecated.
+func reloadFileDescriptor(deprecated bool) *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("reload.proto"),
+		Package: proto.String("reload"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Request"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("a"),
+				Number:   proto.Int32(1),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				JsonName: proto.String("a"),
+				Options:  &descriptorpb.FieldOptions{Deprecated: proto.Bool(deprecated)},
+			}},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Service"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{
+					Name:       proto.String("Get"),
+					InputType:  proto.String(".reload.Request"),
+					OutputType: proto.String(".reload.Request"),
+					Options:    &descriptorpb.MethodOptions{Deprecated: proto.Bool(deprecated)},
+				},
+				{
+					Name:       proto.String("Update"),
+					InputType:  proto.String(".reload.Request"),
+					OutputType: proto.String(".reload.Request"),
+				},
+			},
+		}},
+	}
+}
+
+func reloadFiles(t *testing.T, deprecated bool) *protoregistry.Files {
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{reloadFileDescriptor(deprecated)}})
+	require.NoError(t, err)
+	return files
+}
+
+func reloadRequest(t *testing.T, files *protoregistry.Files) proto.Message {
+	desc, err := files.FindDescriptorByName("reload.Request")
+	require.NoError(t, err)
+	md := desc.(protoreflect.MessageDescriptor)
+	msg := dynamicpb.NewMessage(md)
+	msg.Set(md.Fields().ByName("a"), protoreflect.ValueOfString("a"))
+	return msg
+}
+
+func TestReload(t *testing.T) {
+	v1, v2 := reloadFiles(t, false), reloadFiles(t, true)
+	metrics := NewMetrics(WithFiles(v1))
+	call := func(method string, req proto.Message) {
+		_, _ = metrics.UnaryServerInterceptor()(
+			context.Background(), req,
+			&grpc.UnaryServerInfo{FullMethod: "/reload.Service/" + method},
+			func(context.Context, any) (any, error) { return nil, nil },
+		)
+	}
+
+	call("Get", reloadRequest(t, v1))
+	call("Update", reloadRequest(t, v1))
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics.deprecatedMethodUsed))
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics.deprecatedFieldUsed))
+
+	metrics.Reload(v2)
+	call("Get", reloadRequest(t, v2))
+	call("Update", reloadRequest(t, v2))
+	c := metrics.deprecatedMethodUsed.WithLabelValues("unary", "reload.Service", "Get")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c))
+	c = metrics.deprecatedFieldUsed.WithLabelValues("unary", "reload.Service", "Update", "a", "implicit")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c))
+
+	metrics.Reload(v1)
+	call("Get", reloadRequest(t, v1))
+	c = metrics.deprecatedMethodUsed.WithLabelValues("unary", "reload.Service", "Get")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c), "counters are preserved, the method is no longer deprecated")
+}
+
+func TestReload_extensions(t *testing.T) {
+	file := reloadFileDescriptor(false)
+	file.Syntax = proto.String("proto2")
+	file.MessageType[0].ExtensionRange = []*descriptorpb.DescriptorProto_ExtensionRange{{Start: proto.Int32(100), End: proto.Int32(200)}}
+	file.Extension = []*descriptorpb.FieldDescriptorProto{{
+		Name:     proto.String("ext"),
+		Number:   proto.Int32(100),
+		Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Extendee: proto.String(".reload.Request"),
+		Options:  &descriptorpb.FieldOptions{Deprecated: proto.Bool(true)},
+	}}
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}})
+	require.NoError(t, err)
+	desc, err := files.FindDescriptorByName("reload.ext")
+	require.NoError(t, err)
+
+	metrics := NewMetrics()
+	metrics.Reload(files)
+	req := reloadRequest(t, files)
+	req.ProtoReflect().Set(dynamicpb.NewExtensionType(desc.(protoreflect.ExtensionDescriptor)).TypeDescriptor(), protoreflect.ValueOfString("x"))
+	_, _ = metrics.UnaryServerInterceptor()(
+		context.Background(), req,
+		&grpc.UnaryServerInfo{FullMethod: "/reload.Service/Update"},
+		func(context.Context, any) (any, error) { return nil, nil },
+	)
+	c := metrics.deprecatedFieldUsed.WithLabelValues("unary", "reload.Service", "Update", "[reload.ext]", "explicit")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c))
+}
+
+func TestWatchDescriptorSets(t *testing.T) {
+	dir := t.TempDir()
+	write := func(deprecated bool) {
+		raw, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{reloadFileDescriptor(deprecated)}})
+		require.NoError(t, err)
+		path := filepath.Join(dir, "set.binpb")
+		require.NoError(t, os.WriteFile(path+".tmp", raw, 0o644))
+		require.NoError(t, os.Rename(path+".tmp", path))
+	}
+	write(false)
+
+	metrics := NewMetrics()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- metrics.WatchDescriptorSets(ctx, dir, 10*time.Millisecond, nil) }()
+
+	isDeprecated := func() bool {
+		entry := metrics.reporters.Load().method.getOrResolve("/reload.Service/Get")
+		return entry.deprecated
+	}
+	require.Eventually(t, func() bool {
+		return metrics.reporters.Load().method.files.NumFiles() == 1
+	}, time.Second, 5*time.Millisecond, "initial load")
+	assert.False(t, isDeprecated())
+
+	write(true)
+	require.Eventually(t, isDeprecated, time.Second, 5*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	_, err := (&Metrics{}).reloadDescriptorSets(filepath.Join(dir, "missing"), "")
+	assert.Error(t, err)
+}
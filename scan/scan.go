@@ -0,0 +1,512 @@
+// Package scan reports deprecated API usage in stored protobuf data, e.g. to
+// check whether persisted payloads still contain a field before removing it.
+// Payloads of a single message type are read from files in one of several
+// formats, and are evaluated with an apideprecation.Inspector. Unknown fields
+// and fields with reserved numbers are reported as well.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	apideprecation "github.com/belo4ya/grpc-api-deprecation"
+)
+
+// Format is the format of the payloads of a file.
+type Format string
+
+const (
+	// FormatDelimited is binary messages, each prefixed by its varint-encoded
+	// size, as written by protodelim.MarshalTo.
+	FormatDelimited Format = "delimited"
+	// FormatBase64 is one base64-encoded (standard encoding) binary message per line.
+	FormatBase64 Format = "base64"
+	// FormatJSONL is one protojson-encoded message per line.
+	FormatJSONL Format = "jsonl"
+	// FormatText is a single prototext-encoded message per file.
+	FormatText Format = "text"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{FormatDelimited, FormatBase64, FormatJSONL, FormatText}
+
+// Config configures a Scanner.
+type Config struct {
+	// Files provides the descriptors. Defaults to protoregistry.GlobalFiles.
+	Files *protoregistry.Files
+	// Message is the full name of the message type of the payloads.
+	Message protoreflect.FullName
+	// Format is the format of the payloads. Defaults to FormatDelimited.
+	Format Format
+	// MaxSamples is the number of sample offsets kept per finding. Defaults to 5.
+	MaxSamples int
+	// MaxPayloadSize is the maximum size in bytes of a payload, its line, or
+	// the file of FormatText. Larger payloads stop the scan with an error
+	// rather than being read into memory. Defaults to DefaultMaxPayloadSize.
+	MaxPayloadSize int
+}
+
+// DefaultMaxPayloadSize is the default Config.MaxPayloadSize.
+const DefaultMaxPayloadSize = 64 << 20
+
+// Sample locates a payload: its file and the byte offset of the payload, its
+// size prefix, or its line in the file.
+type Sample struct {
+	File   string `json:"file"`
+	Offset int64  `json:"offset"`
+}
+
+// ElementUsage is the aggregated usage of a deprecated field or enum value at
+// a field path, e.g. "items[].state".
+type ElementUsage struct {
+	Element apideprecation.Element `json:"element"`
+	Path    string                 `json:"path"`
+	Count   int                    `json:"count"`
+	Samples []Sample               `json:"samples"`
+}
+
+// UnknownField is the aggregated usage of a field that is not defined by a
+// message type, e.g. of a removed field. Unknown fields are found by number in
+// binary payloads and by name in FormatJSONL payloads. FormatText payloads with
+// unknown fields are invalid, as prototext rejects them.
+type UnknownField struct {
+	Message protoreflect.FullName `json:"message"`
+	// Number is the field number in binary payloads.
+	Number protoreflect.FieldNumber `json:"number,omitempty"`
+	// Name is the field name in JSON payloads.
+	Name string `json:"name,omitempty"`
+	// Reserved is set if the number or name is reserved by the message type.
+	Reserved bool     `json:"reserved"`
+	Count    int      `json:"count"`
+	Samples  []Sample `json:"samples"`
+}
+
+// Report is the result of a scan. Findings are sorted by their element, path,
+// message, and number.
+type Report struct {
+	Message protoreflect.FullName `json:"message"`
+	// Payloads is the number of payloads read, including the Invalid ones.
+	Payloads int            `json:"payloads"`
+	Elements []ElementUsage `json:"elements"`
+	Unknown  []UnknownField `json:"unknown"`
+	// Invalid is the number of payloads that could not be decoded.
+	Invalid        int      `json:"invalid"`
+	InvalidSamples []Sample `json:"invalid_samples"`
+}
+
+// Scanner scans files and aggregates their findings. It is not safe for
+// concurrent use.
+type Scanner struct {
+	cfg       Config
+	msgType   protoreflect.MessageType
+	types     *protoregistry.Types
+	inspector *apideprecation.Inspector
+
+	report   Report
+	elements map[elementKey]*ElementUsage
+	unknown  map[unknownKey]*UnknownField
+}
+
+type elementKey struct {
+	element apideprecation.Element
+	path    string
+}
+
+type unknownKey struct {
+	message protoreflect.FullName
+	number  protoreflect.FieldNumber
+	name    string
+}
+
+// NewScanner creates a Scanner of the payloads of cfg.Message.
+func NewScanner(cfg Config) (*Scanner, error) {
+	if cfg.Files == nil {
+		cfg.Files = protoregistry.GlobalFiles
+	}
+	if cfg.Format == "" {
+		cfg.Format = FormatDelimited
+	}
+	if !slices.Contains(Formats, cfg.Format) {
+		return nil, fmt.Errorf("unknown format %q", cfg.Format)
+	}
+	if cfg.MaxSamples <= 0 {
+		cfg.MaxSamples = 5
+	}
+	if cfg.MaxPayloadSize <= 0 {
+		cfg.MaxPayloadSize = DefaultMaxPayloadSize
+	}
+	desc, err := cfg.Files.FindDescriptorByName(cfg.Message)
+	if err != nil {
+		return nil, fmt.Errorf("message %s: %w", cfg.Message, err)
+	}
+	md, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message", cfg.Message)
+	}
+	types, err := dynamicTypes(cfg.Files)
+	if err != nil {
+		return nil, err
+	}
+	return &Scanner{
+		cfg:       cfg,
+		msgType:   dynamicpb.NewMessageType(md),
+		types:     types,
+		inspector: apideprecation.NewInspector(apideprecation.WithFiles(cfg.Files), apideprecation.WithExtensionTypes(types)),
+		report:    Report{Message: cfg.Message},
+		elements:  map[elementKey]*ElementUsage{},
+		unknown:   map[unknownKey]*UnknownField{},
+	}, nil
+}
+
+// dynamicTypes returns the dynamic message and extension types of files, to
+// resolve extensions and google.protobuf.Any messages.
+func dynamicTypes(files *protoregistry.Files) (*protoregistry.Types, error) {
+	types := &protoregistry.Types{}
+	var err error
+	var addExtensions func(xds protoreflect.ExtensionDescriptors)
+	addExtensions = func(xds protoreflect.ExtensionDescriptors) {
+		for i := range xds.Len() {
+			err = cmp.Or(err, types.RegisterExtension(dynamicpb.NewExtensionType(xds.Get(i))))
+		}
+	}
+	var addMessages func(mds protoreflect.MessageDescriptors)
+	addMessages = func(mds protoreflect.MessageDescriptors) {
+		for i := range mds.Len() {
+			md := mds.Get(i)
+			err = cmp.Or(err, types.RegisterMessage(dynamicpb.NewMessageType(md)))
+			addExtensions(md.Extensions())
+			addMessages(md.Messages())
+		}
+	}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		addMessages(fd.Messages())
+		addExtensions(fd.Extensions())
+		return err == nil
+	})
+	return types, err
+}
+
+// ScanFile scans the payloads of the file at path.
+func (s *Scanner) ScanFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.Scan(path, f)
+}
+
+// Scan scans the payloads read from r. name identifies r in samples, e.g. its
+// file path. Payloads that cannot be decoded are counted as invalid, but
+// malformed size prefixes of FormatDelimited and payloads exceeding
+// MaxPayloadSize stop the scan with an error.
+func (s *Scanner) Scan(name string, r io.Reader) error {
+	switch s.cfg.Format {
+	case FormatDelimited:
+		return s.scanDelimited(name, bufio.NewReader(r))
+	case FormatText:
+		data, err := io.ReadAll(io.LimitReader(r, int64(s.cfg.MaxPayloadSize)+1))
+		if err != nil {
+			return err
+		}
+		if len(data) > s.cfg.MaxPayloadSize {
+			return fmt.Errorf("%s: payload size exceeds limit %d", name, s.cfg.MaxPayloadSize)
+		}
+		s.scanPayload(Sample{File: name}, func(msg proto.Message) error {
+			return prototext.UnmarshalOptions{Resolver: s.types}.Unmarshal(data, msg)
+		})
+		return nil
+	default:
+		return s.scanLines(name, r)
+	}
+}
+
+func (s *Scanner) scanDelimited(name string, r *bufio.Reader) error {
+	var offset int64
+	for {
+		size, n, err := readUvarint(r)
+		if errors.Is(err, io.EOF) && n == 0 {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: offset %d: read size: %w", name, offset, err)
+		}
+		if size > uint64(s.cfg.MaxPayloadSize) {
+			return fmt.Errorf("%s: offset %d: payload size %d exceeds limit %d", name, offset, size, s.cfg.MaxPayloadSize)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return fmt.Errorf("%s: offset %d: read message: %w", name, offset, err)
+		}
+		s.scanPayload(Sample{File: name, Offset: offset}, func(msg proto.Message) error {
+			return proto.UnmarshalOptions{Resolver: s.types}.Unmarshal(data, msg)
+		})
+		offset += int64(n) + int64(size)
+	}
+}
+
+// readUvarint reads a varint and returns the number of bytes read.
+func readUvarint(r *bufio.Reader) (uint64, int, error) {
+	var buf []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(buf) != 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, len(buf), err
+		}
+		buf = append(buf, b)
+		if b < 0x80 {
+			v, n := protowire.ConsumeVarint(buf)
+			if n < 0 {
+				return 0, len(buf), protowire.ParseError(n)
+			}
+			return v, n, nil
+		}
+		if len(buf) == protowire.SizeVarint(1<<63) {
+			return 0, len(buf), errors.New("size prefix overflows 64 bits")
+		}
+	}
+}
+
+func (s *Scanner) scanLines(name string, r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, s.cfg.MaxPayloadSize+len("\r\n"))
+	var offset, consumed int64 // offset of the current and the next line
+	sc.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		consumed += int64(advance)
+		return advance, token, err
+	})
+	for sc.Scan() {
+		line := sc.Bytes()
+		sample := Sample{File: name, Offset: offset}
+		offset = consumed
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if s.cfg.Format == FormatJSONL {
+			if s.scanPayload(sample, func(msg proto.Message) error {
+				return protojson.UnmarshalOptions{Resolver: s.types, DiscardUnknown: true}.Unmarshal(line, msg)
+			}) {
+				s.scanUnknownJSON(s.msgType.Descriptor(), line, sample)
+			}
+			continue
+		}
+		s.scanPayload(sample, func(msg proto.Message) error {
+			data, err := base64.StdEncoding.AppendDecode(nil, bytes.TrimSpace(line))
+			if err != nil {
+				return err
+			}
+			return proto.UnmarshalOptions{Resolver: s.types}.Unmarshal(data, msg)
+		})
+	}
+	if errors.Is(sc.Err(), bufio.ErrTooLong) {
+		return fmt.Errorf("%s: offset %d: line exceeds limit %d", name, offset, s.cfg.MaxPayloadSize)
+	}
+	return sc.Err()
+}
+
+// scanUnknownJSON records the object keys of the JSON payload data of md and
+// its nested messages that are not fields, as protojson discards them. Nested
+// messages with a special JSON mapping, e.g. google.protobuf.Struct, are skipped.
+func (s *Scanner) scanUnknownJSON(md protoreflect.MessageDescriptor, data []byte, sample Sample) {
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(data, &obj) != nil {
+		return
+	}
+	for _, key := range slices.Sorted(maps.Keys(obj)) {
+		if strings.HasPrefix(key, "[") || strings.HasPrefix(key, "@") { // extensions and Any types
+			continue
+		}
+		fd := md.Fields().ByJSONName(key)
+		if fd == nil {
+			fd = md.Fields().ByTextName(key)
+		}
+		if fd == nil {
+			s.addUnknown(unknownKey{message: md.FullName(), name: key}, isReservedJSONName(md, key), sample)
+			continue
+		}
+		if fd.Message() == nil || fd.Message().FullName().Parent() == "google.protobuf" {
+			continue
+		}
+		switch {
+		case fd.IsMap():
+			var values map[string]json.RawMessage
+			if fd.MapValue().Message() != nil && json.Unmarshal(obj[key], &values) == nil {
+				for _, k := range slices.Sorted(maps.Keys(values)) {
+					s.scanUnknownJSON(fd.MapValue().Message(), values[k], sample)
+				}
+			}
+		case fd.IsList():
+			var items []json.RawMessage
+			if json.Unmarshal(obj[key], &items) == nil {
+				for _, item := range items {
+					s.scanUnknownJSON(fd.Message(), item, sample)
+				}
+			}
+		default:
+			s.scanUnknownJSON(fd.Message(), obj[key], sample)
+		}
+	}
+}
+
+// isReservedJSONName reports whether key is a name reserved by md, either as
+// is or in its JSON form, e.g. "oldName" for the reserved name "old_name".
+func isReservedJSONName(md protoreflect.MessageDescriptor, key string) bool {
+	names := md.ReservedNames()
+	if names.Has(protoreflect.Name(key)) {
+		return true
+	}
+	for i := range names.Len() {
+		if jsonCamelCase(string(names.Get(i))) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonCamelCase converts a field name to its default JSON name as protoc does:
+// underscores are dropped and the letter following them is upper-cased.
+func jsonCamelCase(s string) string {
+	var b strings.Builder
+	upper := false
+	for i := range len(s) {
+		c := s[i]
+		switch {
+		case c == '_':
+			upper = true
+		case upper && 'a' <= c && c <= 'z':
+			b.WriteByte(c - 'a' + 'A')
+			upper = false
+		default:
+			b.WriteByte(c)
+			upper = false
+		}
+	}
+	return b.String()
+}
+
+// scanPayload decodes a payload with unmarshal and records its findings. It
+// reports whether the payload is valid.
+func (s *Scanner) scanPayload(sample Sample, unmarshal func(proto.Message) error) bool {
+	s.report.Payloads++
+	msg := s.msgType.New().Interface()
+	if err := unmarshal(msg); err != nil {
+		s.report.Invalid++
+		s.report.InvalidSamples = s.addSample(s.report.InvalidSamples, sample)
+		return false
+	}
+
+	for _, u := range s.inspector.Inspect(msg) {
+		key := elementKey{element: u.Element, path: u.Path}
+		e, ok := s.elements[key]
+		if !ok {
+			e = &ElementUsage{Element: u.Element, Path: u.Path}
+			s.elements[key] = e
+		}
+		e.Count++
+		e.Samples = s.addSample(e.Samples, sample)
+	}
+	s.scanUnknown(msg.ProtoReflect(), sample)
+	return true
+}
+
+// scanUnknown records the unknown fields of msg and its nested messages.
+func (s *Scanner) scanUnknown(msg protoreflect.Message, sample Sample) {
+	md := msg.Descriptor()
+	for b := msg.GetUnknown(); len(b) > 0; {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			break
+		}
+		m := protowire.ConsumeFieldValue(num, typ, b[n:])
+		if m < 0 {
+			break
+		}
+		b = b[n+m:]
+
+		s.addUnknown(unknownKey{message: md.FullName(), number: num}, md.ReservedRanges().Has(num), sample)
+	}
+
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			list := v.List()
+			for i := range list.Len() {
+				s.scanUnknown(list.Get(i).Message(), sample)
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+				s.scanUnknown(v.Message(), sample)
+				return true
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			s.scanUnknown(v.Message(), sample)
+		}
+		return true
+	})
+}
+
+func (s *Scanner) addUnknown(key unknownKey, reserved bool, sample Sample) {
+	u, ok := s.unknown[key]
+	if !ok {
+		u = &UnknownField{Message: key.message, Number: key.number, Name: key.name, Reserved: reserved}
+		s.unknown[key] = u
+	}
+	u.Count++
+	u.Samples = s.addSample(u.Samples, sample)
+}
+
+// addSample adds sample to samples, unless there are MaxSamples already or its
+// payload is the last sample, e.g. for repeated usages in the same payload.
+func (s *Scanner) addSample(samples []Sample, sample Sample) []Sample {
+	if len(samples) >= s.cfg.MaxSamples || (len(samples) != 0 && samples[len(samples)-1] == sample) {
+		return samples
+	}
+	return append(samples, sample)
+}
+
+// Report returns the findings of the files scanned so far.
+func (s *Scanner) Report() Report {
+	report := s.report
+	report.Elements = make([]ElementUsage, 0, len(s.elements))
+	for _, e := range s.elements {
+		report.Elements = append(report.Elements, *e)
+	}
+	slices.SortFunc(report.Elements, func(a, b ElementUsage) int {
+		return cmp.Or(
+			cmp.Compare(a.Element.Kind, b.Element.Kind),
+			cmp.Compare(a.Element.Name, b.Element.Name),
+			cmp.Compare(a.Path, b.Path),
+		)
+	})
+	report.Unknown = make([]UnknownField, 0, len(s.unknown))
+	for _, u := range s.unknown {
+		report.Unknown = append(report.Unknown, *u)
+	}
+	slices.SortFunc(report.Unknown, func(a, b UnknownField) int {
+		return cmp.Or(cmp.Compare(a.Message, b.Message), cmp.Compare(a.Number, b.Number), cmp.Compare(a.Name, b.Name))
+	})
+	return report
+}
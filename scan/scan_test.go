@@ -0,0 +1,159 @@
+package scan
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	apideprecation "github.com/belo4ya/grpc-api-deprecation"
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+var (
+	mail = apideprecation.Element{Kind: apideprecation.ElementField, Name: "testdata.Contact.mail"}
+	name = apideprecation.Element{Kind: apideprecation.ElementField, Name: "testdata.Contact.name"}
+)
+
+func TestScanner_Delimited(t *testing.T) {
+	var buf bytes.Buffer
+	payloads := []*pb.Contact{
+		{Email: "a@example.com"},
+		{Mail: "b@example.com", Children: []*pb.Contact{{Mail: "c@example.com"}}},
+		{Mail: "d@example.com", Name: "d"},
+	}
+	var offsets []int64
+	for _, p := range payloads {
+		offsets = append(offsets, int64(buf.Len()))
+		_, err := protodelim.MarshalTo(&buf, p)
+		require.NoError(t, err)
+	}
+	// A payload with a reserved and an unknown field.
+	offsets = append(offsets, int64(buf.Len()))
+	raw, err := proto.Marshal(&pb.Contact{Email: "e@example.com"})
+	require.NoError(t, err)
+	raw = protowire.AppendString(protowire.AppendTag(raw, 5, protowire.BytesType), "removed")
+	raw = protowire.AppendVarint(protowire.AppendTag(raw, 60, protowire.VarintType), 1)
+	buf.Write(protowire.AppendVarint(nil, uint64(len(raw))))
+	buf.Write(raw)
+
+	s, err := NewScanner(Config{Message: "testdata.Contact", MaxSamples: 1})
+	require.NoError(t, err)
+	require.NoError(t, s.Scan("contacts.bin", &buf))
+
+	assert.Equal(t, Report{
+		Message:  "testdata.Contact",
+		Payloads: 4,
+		Elements: []ElementUsage{
+			{Element: mail, Path: "children[].mail", Count: 1, Samples: []Sample{{File: "contacts.bin", Offset: offsets[1]}}},
+			{Element: mail, Path: "mail", Count: 2, Samples: []Sample{{File: "contacts.bin", Offset: offsets[1]}}},
+			{Element: name, Path: "name", Count: 1, Samples: []Sample{{File: "contacts.bin", Offset: offsets[2]}}},
+		},
+		Unknown: []UnknownField{
+			{Message: "testdata.Contact", Number: 5, Reserved: true, Count: 1, Samples: []Sample{{File: "contacts.bin", Offset: offsets[3]}}},
+			{Message: "testdata.Contact", Number: 60, Count: 1, Samples: []Sample{{File: "contacts.bin", Offset: offsets[3]}}},
+		},
+	}, s.Report())
+
+	assert.Error(t, s.Scan("truncated.bin", bytes.NewReader([]byte{10, 1})))
+}
+
+func TestScanner_Lines(t *testing.T) {
+	msg := &pb.Contact{Mail: "a@example.com"}
+	raw, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	js, err := protojson.Marshal(msg)
+	require.NoError(t, err)
+	txt, err := prototext.Marshal(msg)
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		format Format
+		data   string
+	}{
+		{FormatBase64, "not base64\n\n" + base64.StdEncoding.EncodeToString(raw) + "\n"},
+		{FormatJSONL, "{not json\n\n" + string(js) + "\n"},
+		{FormatText, string(txt)},
+	} {
+		t.Run(string(tt.format), func(t *testing.T) {
+			s, err := NewScanner(Config{Message: "testdata.Contact", Format: tt.format})
+			require.NoError(t, err)
+			require.NoError(t, s.Scan("contacts", strings.NewReader(tt.data)))
+
+			report := s.Report()
+			require.Len(t, report.Elements, 1)
+			assert.Equal(t, mail, report.Elements[0].Element)
+			if tt.format == FormatText {
+				assert.Equal(t, 1, report.Payloads)
+				assert.Equal(t, []Sample{{File: "contacts"}}, report.Elements[0].Samples)
+				return
+			}
+			assert.Equal(t, 2, report.Payloads)
+			assert.Equal(t, 1, report.Invalid)
+			assert.Equal(t, []Sample{{File: "contacts"}}, report.InvalidSamples)
+			line := int64(strings.Index(tt.data, "\n\n") + 2)
+			assert.Equal(t, []Sample{{File: "contacts", Offset: line}}, report.Elements[0].Samples)
+		})
+	}
+}
+
+func TestScanner_JSONUnknown(t *testing.T) {
+	first := `{"mail": "a@example.com", "fax": "1", "homePage": "x"}` + "\r\n"
+	data := first + `{"children": [{"pager": "2"}], "@type": "x"}` + "\r\n"
+
+	s, err := NewScanner(Config{Message: "testdata.Contact", Format: FormatJSONL})
+	require.NoError(t, err)
+	require.NoError(t, s.Scan("contacts.jsonl", strings.NewReader(data)))
+
+	report := s.Report()
+	assert.Equal(t, 2, report.Payloads)
+	assert.Zero(t, report.Invalid)
+	require.Len(t, report.Elements, 1)
+	assert.Equal(t, []Sample{{File: "contacts.jsonl"}}, report.Elements[0].Samples)
+	assert.Equal(t, []UnknownField{
+		{Message: "testdata.Contact", Name: "fax", Reserved: true, Count: 1, Samples: []Sample{{File: "contacts.jsonl"}}},
+		{Message: "testdata.Contact", Name: "homePage", Reserved: true, Count: 1, Samples: []Sample{{File: "contacts.jsonl"}}},
+		{Message: "testdata.Contact", Name: "pager", Count: 1, Samples: []Sample{{File: "contacts.jsonl", Offset: int64(len(first))}}},
+	}, report.Unknown)
+}
+
+func TestScanner_MaxPayloadSize(t *testing.T) {
+	s, err := NewScanner(Config{Message: "testdata.Contact"})
+	require.NoError(t, err)
+	err = s.Scan("corrupt.bin", bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}))
+	assert.ErrorContains(t, err, "corrupt.bin: offset 0: payload size 18446744073709551615 exceeds limit")
+
+	raw, err := proto.Marshal(&pb.Contact{Mail: "a@example.com"})
+	require.NoError(t, err)
+	for _, format := range []Format{FormatDelimited, FormatBase64, FormatText} {
+		s, err := NewScanner(Config{Message: "testdata.Contact", Format: format, MaxPayloadSize: 4})
+		require.NoError(t, err)
+		var data []byte
+		switch format {
+		case FormatDelimited:
+			data = append(protowire.AppendVarint(nil, uint64(len(raw))), raw...)
+		case FormatBase64:
+			data = []byte(base64.StdEncoding.EncodeToString(raw) + "\n")
+		case FormatText:
+			data = []byte(`mail: "a@example.com"`)
+		}
+		assert.ErrorContains(t, s.Scan("big", bytes.NewReader(data)), "exceeds limit 4", format)
+	}
+}
+
+func TestNewScanner(t *testing.T) {
+	_, err := NewScanner(Config{Message: "testdata.Unknown"})
+	assert.Error(t, err)
+	_, err = NewScanner(Config{Message: "testdata.State"})
+	assert.Error(t, err)
+	_, err = NewScanner(Config{Message: "testdata.Contact", Format: "xml"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,126 @@
+package apideprecation
+
+import (
+	"cmp"
+	"slices"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Site is a place where usage of a deprecated element is reported: the RPC
+// method and, for fields and enum values, the field path within the method's
+// request message. Service, Method, and Field match the grpc_service,
+// grpc_method, and field label values of the counters exposed by Metrics.
+type Site struct {
+	Element    Element
+	Descriptor protoreflect.Descriptor
+	Service    string
+	Method     string
+	Field      string
+}
+
+// Sites returns the sites of deprecated elements for every method of every
+// service registered in files. If files is nil, protoregistry.GlobalFiles is used.
+//
+// Sites are a static approximation of what Metrics reports:
+//   - recursive messages are expanded one level deep: a message type appears at
+//     most twice on a path, so deeper recursive paths are not listed;
+//   - extension fields are not listed, as extensions are resolved with
+//     WithExtensionTypes rather than files;
+//   - fields referenced by FieldMasks (see WithFieldMasks) are not listed;
+//   - field paths are rendered with the default FieldPathNames style and
+//     without map keys, so they do not match counters of Metrics configured
+//     with another WithFieldPathStyle or with WithFieldPathKeys.
+func Sites(files *protoregistry.Files) []Site {
+	if files == nil {
+		files = protoregistry.GlobalFiles
+	}
+	var sites []Site
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := range services.Len() {
+			methods := services.Get(i).Methods()
+			for j := range methods.Len() {
+				sites = appendMethodSites(sites, methods.Get(j))
+			}
+		}
+		return true
+	})
+	slices.SortFunc(sites, func(a, b Site) int {
+		return cmp.Or(
+			cmp.Compare(a.Service, b.Service),
+			cmp.Compare(a.Method, b.Method),
+			compareElements(a.Element, b.Element),
+			cmp.Compare(a.Field, b.Field),
+		)
+	})
+	return sites
+}
+
+func appendMethodSites(sites []Site, md protoreflect.MethodDescriptor) []Site {
+	site := Site{Service: string(md.Parent().FullName()), Method: string(md.Name())}
+	if isMethodOrServiceDeprecated(md) {
+		// Fields are not reported for deprecated methods.
+		site.Element, site.Descriptor = methodElement(md), md
+		return append(sites, site)
+	}
+
+	w := &sitesWalker{site: site, path: newFieldPath(), visiting: map[protoreflect.FullName]int{}}
+	defer w.path.Release()
+	w.walkMessage(md.Input())
+	return append(sites, w.sites...)
+}
+
+type sitesWalker struct {
+	site     Site
+	path     *fieldPath
+	visiting map[protoreflect.FullName]int
+	sites    []Site
+}
+
+func (w *sitesWalker) walkMessage(md protoreflect.MessageDescriptor) {
+	if w.visiting[md.FullName()] == 2 {
+		return
+	}
+	w.visiting[md.FullName()]++
+	defer func() { w.visiting[md.FullName()]-- }()
+
+	fields := md.Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		w.path.Push(renderFieldPathPart(fd))
+		w.walkField(fd)
+		w.path.Pop()
+	}
+}
+
+func (w *sitesWalker) walkField(fd protoreflect.FieldDescriptor) {
+	if isFieldDeprecated(fd) {
+		w.add(fieldElement(fd), fd)
+		return
+	}
+
+	kind, md, ed := fd.Kind(), fd.Message(), fd.Enum()
+	if fd.IsMap() {
+		mv := fd.MapValue()
+		kind, md, ed = mv.Kind(), mv.Message(), mv.Enum()
+	}
+	switch kind {
+	case protoreflect.MessageKind:
+		w.walkMessage(md)
+	case protoreflect.EnumKind:
+		values := ed.Values()
+		for i := range values.Len() {
+			if evd := values.Get(i); isEnumValueDeprecated(evd) {
+				w.add(enumValueElement(evd), evd)
+			}
+		}
+	}
+}
+
+func (w *sitesWalker) add(element Element, desc protoreflect.Descriptor) {
+	site := w.site
+	site.Element, site.Descriptor, site.Field = element, desc, w.path.Render()
+	w.sites = append(w.sites, site)
+}
@@ -0,0 +1,68 @@
+package apideprecation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestSites(t *testing.T) {
+	type site struct {
+		element         Element
+		service, method string
+		field           string
+	}
+	var got []site
+	for _, s := range Sites(nil) {
+		if strings.HasPrefix(s.Service, "testdata.") {
+			got = append(got, site{element: s.Element, service: s.Service, method: s.Method, field: s.Field})
+		}
+	}
+
+	title := Element{Kind: ElementField, Name: "testdata.Resource.title"}
+	legacy := Element{Kind: ElementEnumValue, Name: "testdata.STATE_LEGACY"}
+	svc, legacySvc := "testdata.ResourceService", "testdata.LegacyResourceService"
+	assert.Equal(t, []site{
+		{element: Element{Kind: ElementMethod, Name: "testdata.LegacyResourceService.GetResource"}, service: legacySvc, method: "GetResource"},
+		{element: Element{Kind: ElementMethod, Name: "testdata.ResourceService.GetResourceLegacy"}, service: svc, method: "GetResourceLegacy"},
+		{element: legacy, service: svc, method: "UpdateResource", field: "resource.children[].state"},
+		{element: legacy, service: svc, method: "UpdateResource", field: "resource.children[].states"},
+		{element: legacy, service: svc, method: "UpdateResource", field: "resource.state"},
+		{element: legacy, service: svc, method: "UpdateResource", field: "resource.states"},
+		{element: title, service: svc, method: "UpdateResource", field: "resource.children[].title"},
+		{element: title, service: svc, method: "UpdateResource", field: "resource.title"},
+		{element: legacy, service: svc, method: "WatchResources", field: "children[].state"},
+		{element: legacy, service: svc, method: "WatchResources", field: "children[].states"},
+		{element: legacy, service: svc, method: "WatchResources", field: "state"},
+		{element: legacy, service: svc, method: "WatchResources", field: "states"},
+		{element: title, service: svc, method: "WatchResources", field: "children[].title"},
+		{element: title, service: svc, method: "WatchResources", field: "title"},
+	}, got)
+}
+
+func TestDeprecationDetails(t *testing.T) {
+	resource := (&pb.Resource{}).ProtoReflect().Descriptor()
+	services := pb.File_service_proto.Services()
+
+	details := DeprecationDetails(resource.Fields().ByName("title"))
+	assert.Equal(t, "2025-03-01", details.GetEffectiveAt())
+	assert.Equal(t, "Use display_name instead.", details.GetDescription())
+
+	details = DeprecationDetails(pb.State_STATE_LEGACY.Descriptor().Values().ByNumber(2))
+	assert.Equal(t, "2025-04-01", details.GetEffectiveAt())
+
+	details = DeprecationDetails(services.ByName("ResourceService").Methods().ByName("GetResourceLegacy"))
+	assert.Equal(t, "2025-06-01", details.GetEffectiveAt())
+
+	details = DeprecationDetails(services.ByName("LegacyResourceService").Methods().ByName("GetResource"))
+	assert.Equal(t, "2025-01-01", details.GetEffectiveAt(), "inherited from service")
+
+	owner := (&pb.Owner{}).ProtoReflect().Descriptor()
+	details = DeprecationDetails(owner.Fields().ByName("legacy_number"))
+	assert.Equal(t, "2025-07-01", details.GetEffectiveAt(), "inherited from oneof")
+
+	assert.Nil(t, DeprecationDetails(resource.Fields().ByName("name")))
+}
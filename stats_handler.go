@@ -0,0 +1,88 @@
+package apideprecation
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/protobuf/proto"
+)
+
+// StatsHandler returns a stats.Handler that records deprecated RPC method,
+// field, and enum usage from RPC payloads instead of interceptors: on servers
+// (grpc.StatsHandler) it observes received request messages, on clients
+// (grpc.WithStatsHandler) it observes sent request messages. Both sides
+// increment the same counters, see SideLabel.
+//
+// Unlike interceptors, it does not depend on the order of the interceptor
+// chain: calls rejected by an auth or validation interceptor, and messages
+// read by custom stream wrappers, are recorded as well. Do not combine it with
+// the interceptors of the same Metrics, or usage is recorded twice.
+//
+// With WithWarnings, warnings are sent for unary calls only: gRPC does not
+// expose the stream to stats handlers of streaming calls. Stats handlers cannot
+// fail calls, so brownouts and policy rejections are not enforced.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func (m *Metrics) StatsHandler() stats.Handler {
+	return &statsHandler{metrics: m}
+}
+
+type statsHandler struct {
+	metrics *Metrics
+}
+
+type statsRPCKey struct{}
+
+// statsRPC is the state of a single RPC attached to its context by TagRPC.
+type statsRPC struct {
+	meta CallMeta
+}
+
+func (h *statsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, statsRPCKey{}, &statsRPC{meta: newCallMeta(info.FullMethodName, nil)})
+}
+
+func (h *statsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	rpc, ok := ctx.Value(statsRPCKey{}).(*statsRPC)
+	if !ok {
+		return
+	}
+
+	switch s := s.(type) {
+	case *stats.Begin:
+		if s.IsClientStream || s.IsServerStream {
+			rpc.meta = newCallMeta(rpc.meta.FullMethod, &grpc.StreamServerInfo{
+				IsClientStream: s.IsClientStream,
+				IsServerStream: s.IsServerStream,
+			})
+		}
+		if s.Client {
+			rpc.meta.Side = SideClient
+		}
+	case *stats.InPayload:
+		if s.IsClient() {
+			return
+		}
+		if msg, ok := s.Payload.(proto.Message); ok {
+			if warns, _ := h.metrics.observe(ctx, msg, rpc.meta, nil); len(warns) != 0 {
+				_ = grpc.SetHeader(ctx, metadata.Pairs(warns.pairs()...)) // fails for streaming calls
+			}
+		}
+	case *stats.OutPayload:
+		if !s.IsClient() {
+			return
+		}
+		if msg, ok := s.Payload.(proto.Message); ok {
+			_, _ = h.metrics.observe(ctx, msg, rpc.meta, nil)
+		}
+	}
+}
+
+func (h *statsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *statsHandler) HandleConn(context.Context, stats.ConnStats) {}
+
+var _ stats.Handler = (*statsHandler)(nil)
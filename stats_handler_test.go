@@ -0,0 +1,102 @@
+package apideprecation
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestStatsHandler(t *testing.T) {
+	sideLabels := WithExtraLabels(LabelSet{Field: []Label{SideLabel()}})
+	serverMetrics := NewMetrics(WithWarnings(), sideLabels)
+	clientMetrics := NewMetrics(sideLabels)
+	sides := map[string]*Metrics{SideServer: serverMetrics, SideClient: clientMetrics}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.StatsHandler(serverMetrics.StatsHandler()),
+		grpc.UnaryInterceptor(func(context.Context, any, *grpc.UnaryServerInfo, grpc.UnaryHandler) (any, error) {
+			return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+		}),
+	)
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "testdata.ResourceService",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "UpdateResource",
+			Handler: func(_ any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				req := &pb.UpdateResourceRequest{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				info := &grpc.UnaryServerInfo{FullMethod: "/testdata.ResourceService/UpdateResource"}
+				return interceptor(ctx, req, info, func(context.Context, any) (any, error) { return &pb.Resource{}, nil })
+			},
+		}},
+		Streams: []grpc.StreamDesc{{
+			StreamName: "WatchResources",
+			Handler: func(_ any, stream grpc.ServerStream) error {
+				for {
+					if err := stream.RecvMsg(&pb.Resource{}); err != nil {
+						return nil
+					}
+				}
+			},
+			ClientStreams: true,
+			ServerStreams: true,
+		}},
+	}, struct{}{})
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(clientMetrics.StatsHandler()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	t.Run("call rejected by interceptor", func(t *testing.T) {
+		var header metadata.MD
+		err := conn.Invoke(context.Background(), "/testdata.ResourceService/UpdateResource",
+			&pb.UpdateResourceRequest{Resource: &pb.Resource{Title: "t"}}, &pb.Resource{}, grpc.Header(&header))
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		assert.Equal(t, []string{
+			`field resource.title (testdata.Resource.title) is deprecated and will stop working on 2025-03-01: Use display_name instead.`,
+		}, header.Get(WarningHeader))
+
+		for side, metrics := range sides {
+			c := metrics.deprecatedFieldUsed.WithLabelValues("unary", "testdata.ResourceService", "UpdateResource", "resource.title", "implicit", side)
+			assert.Equal(t, float64(1), testutil.ToFloat64(c), side)
+		}
+	})
+
+	t.Run("bidi stream", func(t *testing.T) {
+		stream, err := conn.NewStream(context.Background(),
+			&grpc.StreamDesc{ClientStreams: true, ServerStreams: true}, "/testdata.ResourceService/WatchResources")
+		require.NoError(t, err)
+		require.NoError(t, stream.SendMsg(&pb.Resource{Title: "a"}))
+		require.NoError(t, stream.SendMsg(&pb.Resource{Title: "b"}))
+		require.NoError(t, stream.CloseSend())
+		assert.ErrorIs(t, stream.RecvMsg(&pb.Resource{}), io.EOF)
+
+		for side, metrics := range sides {
+			c := metrics.deprecatedFieldUsed.WithLabelValues("bidi_stream", "testdata.ResourceService", "WatchResources", "title", "implicit", side)
+			assert.Equal(t, float64(2), testutil.ToFloat64(c), side)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package apideprecation
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Throttle decisions reported in the "decision" label of ThrottleDecisionsMetricName.
+const (
+	throttleAllowed   = "allowed"
+	throttleThrottled = "throttled"
+)
+
+// ThrottleOption configures the throttling enabled by WithThrottling.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+type ThrottleOption func(*throttler)
+
+// WithThrottleMaxCallers bounds the number of callers whose limiter state is
+// kept. The least recently seen callers are evicted first, starting again with
+// a full bucket. Defaults to 10000.
+func WithThrottleMaxCallers(n int) ThrottleOption {
+	return func(t *throttler) {
+		t.maxCallers = n
+	}
+}
+
+// WithThrottleClock sets the clock used to refill buckets. Defaults to time.Now.
+func WithThrottleClock(now func() time.Time) ThrottleOption {
+	return func(t *throttler) {
+		t.now = now
+	}
+}
+
+// WithThrottling limits the rate of calls using deprecated methods, fields, or
+// enum values to rate calls per second, with bursts of up to burst calls, per
+// caller identified by callerKey. Calls without deprecated usage, or whose
+// deprecated usage is exempted (see WithExemptions), are not limited. Unknown
+// callers share a single limit.
+//
+// Throttled calls fail with a ThrottleError, which has the ResourceExhausted
+// code and a RetryInfo detail. Decisions are counted in ThrottleDecisionsMetricName.
+// A streaming call takes a single token, on its first received message with
+// deprecated usage. Throttling is enforced where brownouts are, see
+// WithBrownouts; elsewhere no tokens are taken and no decisions are counted.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithThrottling(callerKey CallerKeyFunc, rate float64, burst int, opts ...ThrottleOption) Option {
+	return func(c *config) {
+		t := &throttler{
+			callerKey:  callerKey,
+			rate:       rate,
+			burst:      float64(burst),
+			maxCallers: 10000,
+			now:        time.Now,
+			buckets:    map[string]*list.Element{},
+			lru:        list.New(),
+		}
+		for _, opt := range opts {
+			opt(t)
+		}
+		c.throttler = t
+	}
+}
+
+// throttler is a token-bucket limiter per caller, bounded by an LRU list.
+type throttler struct {
+	callerKey  CallerKeyFunc
+	rate       float64
+	burst      float64
+	maxCallers int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element // caller -> *tokenBucket in lru
+	lru     *list.List               // most recently seen first
+}
+
+type tokenBucket struct {
+	caller string
+	tokens float64
+	last   time.Time
+}
+
+// take takes a token from the bucket of caller. If there is none, it returns
+// false and the time until the next token.
+func (t *throttler) take(caller string) (bool, time.Duration) {
+	now := t.now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var b *tokenBucket
+	if e, ok := t.buckets[caller]; ok {
+		t.lru.MoveToFront(e)
+		b = e.Value.(*tokenBucket)
+		b.tokens = math.Min(t.burst, b.tokens+now.Sub(b.last).Seconds()*t.rate)
+		b.last = now
+	} else {
+		if t.lru.Len() >= max(t.maxCallers, 1) {
+			oldest := t.lru.Back()
+			t.lru.Remove(oldest)
+			delete(t.buckets, oldest.Value.(*tokenBucket).caller)
+		}
+		b = &tokenBucket{caller: caller, tokens: t.burst, last: now}
+		t.buckets[caller] = t.lru.PushFront(b)
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if t.rate <= 0 {
+		return false, 0
+	}
+	return false, time.Duration((1 - b.tokens) / t.rate * float64(time.Second))
+}
+
+// ThrottleError is returned for calls throttled by WithThrottling.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+type ThrottleError struct {
+	// RetryAfter is the time until the caller can make another call using
+	// deprecated elements. It is zero if the rate is zero.
+	RetryAfter time.Duration
+}
+
+func (e *ThrottleError) Error() string {
+	return fmt.Sprintf("calls using deprecated API elements are throttled, retry after %s", e.RetryAfter)
+}
+
+// GRPCStatus implements the interface used by the status package to convert
+// errors to gRPC statuses. The status has the ResourceExhausted code and a
+// RetryInfo detail.
+func (e *ThrottleError) GRPCStatus() *status.Status {
+	st := status.New(codes.ResourceExhausted, e.Error())
+	if withDetails, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(e.RetryAfter)}); err == nil {
+		return withDetails
+	}
+	return st
+}
@@ -0,0 +1,83 @@
+package apideprecation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestWithThrottling(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	metrics := NewMetrics(WithThrottling(CallerFromMetadata("x-client"), 0.5, 2,
+		WithThrottleMaxCallers(2),
+		WithThrottleClock(func() time.Time { return now }),
+	))
+	call := func(caller, method string) error {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-client", caller))
+		_, err := metrics.observe(ctx, &pb.GetResourceRequest{}, newCallMeta("/testdata.ResourceService/"+method, nil), &callEnforcement{})
+		return err
+	}
+
+	assert.NoError(t, call("a", "GetResourceLegacy"))
+	assert.NoError(t, call("a", "GetResourceLegacy"))
+	err := call("a", "GetResourceLegacy")
+	var throttleErr *ThrottleError
+	require.ErrorAs(t, err, &throttleErr)
+	assert.Equal(t, 2*time.Second, throttleErr.RetryAfter)
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	assert.Equal(t, 2*time.Second, st.Details()[0].(*errdetails.RetryInfo).GetRetryDelay().AsDuration())
+
+	assert.NoError(t, call("a", "GetResource"), "no deprecated usage")
+
+	now = now.Add(2 * time.Second)
+	assert.NoError(t, call("a", "GetResourceLegacy"), "refilled")
+	assert.Error(t, call("a", "GetResourceLegacy"))
+
+	assert.NoError(t, call("b", "GetResourceLegacy"), "separate bucket")
+	assert.NoError(t, call("c", "GetResourceLegacy"), "evicts a")
+	assert.NoError(t, call("a", "GetResourceLegacy"), "full bucket after eviction")
+
+	c := metrics.throttleDecisions.WithLabelValues("unary", "testdata.ResourceService", "GetResourceLegacy", "allowed")
+	assert.Equal(t, float64(6), testutil.ToFloat64(c))
+	c = metrics.throttleDecisions.WithLabelValues("unary", "testdata.ResourceService", "GetResourceLegacy", "throttled")
+	assert.Equal(t, float64(2), testutil.ToFloat64(c))
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.throttleDecisions))
+}
+
+func TestWithThrottling_perCall(t *testing.T) {
+	metrics := NewMetrics(WithThrottling(CallerFromMetadata("x-client"), 0, 1))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-client", "a"))
+	meta := newCallMeta("/testdata.ResourceService/UpdateResource", nil)
+	req := &pb.UpdateResourceRequest{Resource: &pb.Resource{Title: "t"}}
+
+	// Not enforced, as on the client side and in StatsHandler.
+	for range 3 {
+		_, err := metrics.observe(ctx, req, meta, nil)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics.throttleDecisions))
+
+	// The messages of a stream take a single token.
+	var stream callEnforcement
+	for range 3 {
+		_, err := metrics.observe(ctx, req, meta, &stream)
+		assert.NoError(t, err)
+	}
+	_, err := metrics.observe(ctx, req, meta, &callEnforcement{})
+	assert.Error(t, err, "the only token is taken by the stream")
+
+	c := metrics.throttleDecisions.WithLabelValues("unary", "testdata.ResourceService", "UpdateResource", "allowed")
+	assert.Equal(t, float64(1), testutil.ToFloat64(c))
+}
@@ -0,0 +1,171 @@
+package apideprecation
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// UsageSnapshotVersion is the current version of the UsageSnapshot format.
+const UsageSnapshotVersion = 1
+
+// UsageSnapshot is a serializable copy of UsageTracker state. Snapshots can be
+// persisted across restarts and merged across replicas.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+type UsageSnapshot struct {
+	Version int            `json:"version"`
+	TakenAt time.Time      `json:"taken_at"`
+	Usage   []ElementUsage `json:"usage"`
+}
+
+// Snapshot returns the current tracker state.
+func (t *UsageTracker) Snapshot() UsageSnapshot {
+	return UsageSnapshot{Version: UsageSnapshotVersion, TakenAt: t.now(), Usage: t.Usage()}
+}
+
+// Restore merges the snapshot into the tracker state: counts are summed, and
+// the earliest first-seen and the latest last-seen times are kept. Zero times
+// of the snapshot keep the times of the tracker.
+func (t *UsageTracker) Restore(snapshot UsageSnapshot) error {
+	if snapshot.Version != UsageSnapshotVersion {
+		return fmt.Errorf("unsupported usage snapshot version %d", snapshot.Version)
+	}
+	for _, u := range snapshot.Usage {
+		first, last := timeUnixNano(u.FirstSeen), timeUnixNano(u.LastSeen)
+		t.add(usageKey{element: u.Element, caller: u.Caller}, first, last, u.Count)
+	}
+	return nil
+}
+
+// PersistPeriodically writes a snapshot to path every interval until ctx is
+// done, then writes a final snapshot and returns its error. Use
+// LoadUsageSnapshotFile and Restore on startup to continue from the persisted
+// state.
+//
+// Errors of periodic writes, e.g. a full disk, are passed to onError, if not
+// nil, and the snapshot is written again on the next tick.
+func (t *UsageTracker) PersistPeriodically(ctx context.Context, path string, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return SaveUsageSnapshotFile(path, t.Snapshot())
+		case <-ticker.C:
+			if err := SaveUsageSnapshotFile(path, t.Snapshot()); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// MergeUsageSnapshots combines snapshots, e.g. from many replicas, into a single
+// fleet-wide view. Usage of the same element and caller is merged: counts are
+// summed, and the earliest first-seen and the latest last-seen times are kept.
+// If byCaller is false, usage is additionally aggregated across callers.
+func MergeUsageSnapshots(byCaller bool, snapshots ...UsageSnapshot) UsageSnapshot {
+	merged := UsageSnapshot{Version: UsageSnapshotVersion}
+	var usage []ElementUsage
+	for _, s := range snapshots {
+		if s.TakenAt.After(merged.TakenAt) {
+			merged.TakenAt = s.TakenAt
+		}
+		usage = append(usage, s.Usage...)
+	}
+	merged.Usage = mergeUsage(usage, byCaller)
+	return merged
+}
+
+func mergeUsage(usage []ElementUsage, byCaller bool) []ElementUsage {
+	type key struct {
+		element Element
+		caller  string
+	}
+	index := make(map[key]int, len(usage))
+	var merged []ElementUsage
+	for _, u := range usage {
+		if !byCaller {
+			u.Caller = ""
+		}
+		k := key{element: u.Element, caller: u.Caller}
+		i, ok := index[k]
+		if !ok {
+			index[k] = len(merged)
+			merged = append(merged, u)
+			continue
+		}
+		agg := &merged[i]
+		if !u.FirstSeen.IsZero() && (agg.FirstSeen.IsZero() || u.FirstSeen.Before(agg.FirstSeen)) {
+			agg.FirstSeen = u.FirstSeen
+		}
+		if u.LastSeen.After(agg.LastSeen) {
+			agg.LastSeen = u.LastSeen
+		}
+		agg.Count += u.Count
+	}
+	slices.SortFunc(merged, func(a, b ElementUsage) int {
+		return cmp.Or(compareElements(a.Element, b.Element), cmp.Compare(a.Caller, b.Caller))
+	})
+	return merged
+}
+
+// WriteUsageSnapshot encodes the snapshot as JSON.
+func WriteUsageSnapshot(w io.Writer, snapshot UsageSnapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(snapshot)
+}
+
+// ReadUsageSnapshot decodes a JSON snapshot and checks its version.
+func ReadUsageSnapshot(r io.Reader) (UsageSnapshot, error) {
+	var snapshot UsageSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return UsageSnapshot{}, fmt.Errorf("decode usage snapshot: %w", err)
+	}
+	if snapshot.Version != UsageSnapshotVersion {
+		return UsageSnapshot{}, fmt.Errorf("unsupported usage snapshot version %d", snapshot.Version)
+	}
+	return snapshot, nil
+}
+
+// SaveUsageSnapshotFile atomically writes the snapshot to path.
+func SaveUsageSnapshotFile(path string, snapshot UsageSnapshot) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create usage snapshot: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}()
+	if err := WriteUsageSnapshot(f, snapshot); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write usage snapshot: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write usage snapshot: %w", err)
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// LoadUsageSnapshotFile reads a snapshot from path. A missing file yields an
+// empty snapshot, so it is safe to call on the first start.
+func LoadUsageSnapshotFile(path string) (UsageSnapshot, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return UsageSnapshot{Version: UsageSnapshotVersion}, nil
+	}
+	if err != nil {
+		return UsageSnapshot{}, fmt.Errorf("open usage snapshot: %w", err)
+	}
+	defer f.Close()
+	return ReadUsageSnapshot(f)
+}
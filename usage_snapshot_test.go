@@ -0,0 +1,106 @@
+package apideprecation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageSnapshot(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	field := Element{Kind: ElementField, Name: "AllInclusive.scalar_deprecated"}
+	method := Element{Kind: ElementMethod, Name: "t.Service.Method"}
+
+	replica1 := UsageSnapshot{Version: UsageSnapshotVersion, TakenAt: t0, Usage: []ElementUsage{
+		{Element: field, Caller: "a", FirstSeen: t0, LastSeen: t0.Add(time.Hour), Count: 2},
+		{Element: method, Caller: "a", FirstSeen: t0, LastSeen: t0, Count: 1},
+	}}
+	replica2 := UsageSnapshot{Version: UsageSnapshotVersion, TakenAt: t0.Add(time.Minute), Usage: []ElementUsage{
+		{Element: field, Caller: "b", FirstSeen: t0.Add(-time.Hour), LastSeen: t0, Count: 3},
+	}}
+
+	t.Run("merge by caller", func(t *testing.T) {
+		merged := MergeUsageSnapshots(true, replica1, replica2)
+		assert.Equal(t, t0.Add(time.Minute), merged.TakenAt)
+		assert.Equal(t, []ElementUsage{
+			{Element: field, Caller: "a", FirstSeen: t0, LastSeen: t0.Add(time.Hour), Count: 2},
+			{Element: field, Caller: "b", FirstSeen: t0.Add(-time.Hour), LastSeen: t0, Count: 3},
+			{Element: method, Caller: "a", FirstSeen: t0, LastSeen: t0, Count: 1},
+		}, merged.Usage)
+	})
+
+	t.Run("merge across callers", func(t *testing.T) {
+		merged := MergeUsageSnapshots(false, replica1, replica2)
+		assert.Equal(t, []ElementUsage{
+			{Element: field, FirstSeen: t0.Add(-time.Hour), LastSeen: t0.Add(time.Hour), Count: 5},
+			{Element: method, FirstSeen: t0, LastSeen: t0, Count: 1},
+		}, merged.Usage)
+	})
+
+	t.Run("save, load and restore", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "usage.json")
+
+		empty, err := LoadUsageSnapshotFile(path)
+		require.NoError(t, err)
+		assert.Empty(t, empty.Usage)
+
+		require.NoError(t, SaveUsageSnapshotFile(path, replica1))
+		loaded, err := LoadUsageSnapshotFile(path)
+		require.NoError(t, err)
+
+		tracker := NewUsageTracker(WithTrackerClock(func() time.Time { return t0 }))
+		require.NoError(t, tracker.Restore(loaded))
+		require.NoError(t, tracker.Restore(replica1))
+		assert.Equal(t, []ElementUsage{
+			{Element: field, Caller: "a", FirstSeen: t0, LastSeen: t0.Add(time.Hour), Count: 4},
+			{Element: method, Caller: "a", FirstSeen: t0, LastSeen: t0, Count: 2},
+		}, normalizeUsage(tracker.Snapshot().Usage))
+	})
+
+	t.Run("restore zero times", func(t *testing.T) {
+		tracker := NewUsageTracker()
+		require.NoError(t, tracker.Restore(replica1))
+		require.NoError(t, tracker.Restore(UsageSnapshot{Version: UsageSnapshotVersion, Usage: []ElementUsage{
+			{Element: field, Caller: "a", Count: 1},
+			{Element: field, Caller: "c", Count: 1},
+		}}))
+		assert.Equal(t, []ElementUsage{
+			{Element: field, Caller: "a", FirstSeen: t0, LastSeen: t0.Add(time.Hour), Count: 3},
+			{Element: field, Caller: "c", Count: 1},
+			{Element: method, Caller: "a", FirstSeen: t0, LastSeen: t0, Count: 1},
+		}, normalizeUsage(tracker.Usage()))
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		assert.Error(t, NewUsageTracker().Restore(UsageSnapshot{Version: 42}))
+	})
+}
+
+func TestUsageTracker_PersistPeriodically(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+	path := filepath.Join(dir, "usage.json")
+	tracker := NewUsageTracker()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 100)
+	done := make(chan error)
+	go func() {
+		done <- tracker.PersistPeriodically(ctx, path, 5*time.Millisecond, func(err error) { errs <- err })
+	}()
+
+	require.Error(t, <-errs, "the directory does not exist")
+	require.NoError(t, os.Mkdir(dir, 0o755))
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 5*time.Millisecond, "writes are retried after errors")
+
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,200 @@
+package apideprecation
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// CallerKeyFunc extracts a caller identity (e.g. a client name from metadata
+// or a peer address) from the current call. An empty string means the caller
+// is unknown. See CallerFromMetadata, CallerFromPeer, and CallerFromTLS.
+type CallerKeyFunc func(ctx context.Context, meta CallMeta) string
+
+// UsageTracker keeps in-process usage statistics of deprecated elements: the
+// first-seen and last-seen times and a usage count per element and caller.
+// Unlike Prometheus counters, it can answer which deprecated elements have not
+// been used since a given time. Attach it to Metrics using WithUsageTracker.
+//
+// Records are never evicted, since UnusedSince must not forget any usage: the
+// tracker grows with the number of distinct (element, caller) pairs. Use a
+// CallerKeyFunc with bounded values, e.g. a client name rather than a peer
+// address, when tracking per caller.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+type UsageTracker struct {
+	callerKey CallerKeyFunc
+	files     *protoregistry.Files
+	now       func() time.Time
+
+	usage sync.Map // usageKey -> *usageRecord
+}
+
+// UsageTrackerOption configures a UsageTracker.
+type UsageTrackerOption func(*UsageTracker)
+
+// WithTrackerCallerKey tracks usage separately per caller identity returned by fn.
+// Every distinct caller adds a record per deprecated element it uses, which is
+// never evicted, so fn should return a bounded set of values.
+func WithTrackerCallerKey(fn CallerKeyFunc) UsageTrackerOption {
+	return func(t *UsageTracker) {
+		t.callerKey = fn
+	}
+}
+
+// WithTrackerFiles sets the registry that provides the deprecated inventory for
+// UnusedSince. Defaults to protoregistry.GlobalFiles.
+func WithTrackerFiles(files *protoregistry.Files) UsageTrackerOption {
+	return func(t *UsageTracker) {
+		t.files = files
+	}
+}
+
+// WithTrackerClock overrides the time source. Defaults to time.Now.
+func WithTrackerClock(now func() time.Time) UsageTrackerOption {
+	return func(t *UsageTracker) {
+		t.now = now
+	}
+}
+
+// NewUsageTracker creates an empty UsageTracker.
+func NewUsageTracker(opts ...UsageTrackerOption) *UsageTracker {
+	t := &UsageTracker{files: protoregistry.GlobalFiles, now: time.Now}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// ElementUsage is a usage summary of a deprecated element. FirstSeen and
+// LastSeen are zero and Count is 0 for elements that have never been used.
+type ElementUsage struct {
+	Element   Element   `json:"element"`
+	Caller    string    `json:"caller,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Count     uint64    `json:"count"`
+}
+
+type usageKey struct {
+	element Element
+	caller  string
+}
+
+type usageRecord struct {
+	firstSeen atomic.Int64 // unix nanoseconds, 0 if unknown
+	lastSeen  atomic.Int64 // unix nanoseconds, 0 if unknown
+	count     atomic.Uint64
+}
+
+// newUsageRecord returns a record that is fully initialized before it is
+// published, so concurrent readers never see it with only firstSeen set.
+func newUsageRecord(firstSeen, lastSeen int64, count uint64) *usageRecord {
+	rec := &usageRecord{}
+	rec.firstSeen.Store(firstSeen)
+	rec.lastSeen.Store(lastSeen)
+	rec.count.Store(count)
+	return rec
+}
+
+func (r *usageRecord) add(firstSeen, lastSeen int64, count uint64) {
+	r.count.Add(count)
+	for {
+		first := r.firstSeen.Load()
+		if firstSeen == 0 || (first != 0 && first <= firstSeen) || r.firstSeen.CompareAndSwap(first, firstSeen) {
+			break
+		}
+	}
+	for {
+		last := r.lastSeen.Load()
+		if last >= lastSeen || r.lastSeen.CompareAndSwap(last, lastSeen) {
+			break
+		}
+	}
+}
+
+func (t *UsageTracker) record(ctx context.Context, meta CallMeta, element Element) {
+	key := usageKey{element: element}
+	if t.callerKey != nil {
+		key.caller = t.callerKey(ctx, meta)
+	}
+	now := t.now().UnixNano()
+	t.add(key, now, now, 1)
+}
+
+// add merges a usage into the record of key, creating it if needed.
+func (t *UsageTracker) add(key usageKey, firstSeen, lastSeen int64, count uint64) {
+	if v, ok := t.usage.Load(key); ok {
+		v.(*usageRecord).add(firstSeen, lastSeen, count)
+		return
+	}
+	if v, loaded := t.usage.LoadOrStore(key, newUsageRecord(firstSeen, lastSeen, count)); loaded {
+		v.(*usageRecord).add(firstSeen, lastSeen, count)
+	}
+}
+
+// unixNanoTime is the inverse of timeUnixNano.
+func unixNanoTime(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// timeUnixNano returns the unix nanoseconds of t, or 0 if t is zero, whose
+// UnixNano is undefined.
+func timeUnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// Usage returns the usage of every element observed so far, per caller,
+// sorted by element and caller.
+func (t *UsageTracker) Usage() []ElementUsage {
+	var usages []ElementUsage
+	t.usage.Range(func(k, v any) bool {
+		key, rec := k.(usageKey), v.(*usageRecord)
+		usages = append(usages, ElementUsage{
+			Element:   key.element,
+			Caller:    key.caller,
+			FirstSeen: unixNanoTime(rec.firstSeen.Load()),
+			LastSeen:  unixNanoTime(rec.lastSeen.Load()),
+			Count:     rec.count.Load(),
+		})
+		return true
+	})
+	slices.SortFunc(usages, func(a, b ElementUsage) int {
+		return cmp.Or(compareElements(a.Element, b.Element), cmp.Compare(a.Caller, b.Caller))
+	})
+	return usages
+}
+
+// UnusedSince returns the deprecated elements of the inventory that have not
+// been used by any caller since the given time, including elements that have
+// never been used at all. Usage is aggregated across callers, so Caller is
+// always empty. These elements are candidates for safe removal.
+func (t *UsageTracker) UnusedSince(since time.Time) []ElementUsage {
+	byElement := make(map[Element]ElementUsage)
+	for _, u := range mergeUsage(t.Usage(), false) {
+		byElement[u.Element] = u
+	}
+
+	var unused []ElementUsage
+	for _, element := range Inventory(t.files) {
+		u, ok := byElement[element]
+		if !ok {
+			unused = append(unused, ElementUsage{Element: element})
+			continue
+		}
+		if u.LastSeen.Before(since) {
+			unused = append(unused, u)
+		}
+	}
+	return unused
+}
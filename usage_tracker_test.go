@@ -0,0 +1,80 @@
+//nolint:staticcheck
+package apideprecation
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	pb "github.com/belo4ya/grpc-api-deprecation/internal/testdata/proto/proto"
+)
+
+func TestUsageTracker(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := NewUsageTracker(
+		WithTrackerClock(func() time.Time { return now }),
+		WithTrackerCallerKey(func(ctx context.Context, _ CallMeta) string {
+			caller, _ := ctx.Value("caller").(string)
+			return caller
+		}),
+	)
+	metrics := NewMetrics(WithUsageTracker(tracker))
+	interceptor := metrics.UnaryServerInterceptor()
+	call := func(caller string, req any) {
+		_, err := interceptor(
+			context.WithValue(context.Background(), "caller", caller), req,
+			&grpc.UnaryServerInfo{FullMethod: "/t.Service/Method"},
+			func(ctx context.Context, req any) (any, error) { return nil, nil },
+		)
+		require.NoError(t, err)
+	}
+
+	call("a", &pb.AllInclusive{ScalarDeprecated: 1, Enum: pb.Enum_ENUM_DEPRECATED})
+	now = now.Add(time.Hour)
+	call("b", &pb.AllInclusive{ScalarDeprecated: 1})
+	call("b", &pb.AllInclusive{ScalarDeprecated: 1})
+
+	scalarDeprecated := Element{Kind: ElementField, Name: "AllInclusive.scalar_deprecated"}
+	enumDeprecated := Element{Kind: ElementEnumValue, Name: "ENUM_DEPRECATED"}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	usage := tracker.Usage()
+	assert.Equal(t, []ElementUsage{
+		{Element: enumDeprecated, Caller: "a", FirstSeen: start, LastSeen: start, Count: 1},
+		{Element: scalarDeprecated, Caller: "a", FirstSeen: start, LastSeen: start, Count: 1},
+		{Element: scalarDeprecated, Caller: "b", FirstSeen: now, LastSeen: now, Count: 2},
+	}, normalizeUsage(usage))
+
+	unused := normalizeUsage(tracker.UnusedSince(start.Add(time.Minute)))
+	assert.Contains(t, unused, ElementUsage{Element: enumDeprecated, FirstSeen: start, LastSeen: start, Count: 1})
+	assert.Contains(t, unused, ElementUsage{Element: Element{Kind: ElementField, Name: "Simple.field_deprecated"}})
+	for _, u := range unused {
+		assert.NotEqual(t, scalarDeprecated, u.Element)
+	}
+}
+
+func TestInventory(t *testing.T) {
+	inventory := Inventory(nil)
+	assert.Contains(t, inventory, Element{Kind: ElementField, Name: "AllInclusive.scalar_deprecated"})
+	assert.Contains(t, inventory, Element{Kind: ElementField, Name: "AllInclusive.NestedRecursive.message_deprecated"})
+	assert.Contains(t, inventory, Element{Kind: ElementField, Name: "OneOf.scalar_deprecated"})
+	assert.Contains(t, inventory, Element{Kind: ElementField, Name: "testdata.Owner.legacy_number"}, "member of deprecated oneof")
+	assert.Contains(t, inventory, Element{Kind: ElementEnumValue, Name: "ENUM_DEPRECATED"})
+	assert.NotContains(t, inventory, Element{Kind: ElementField, Name: "AllInclusive.scalar"})
+	assert.True(t, slices.IsSortedFunc(inventory, compareElements))
+}
+
+func normalizeUsage(usage []ElementUsage) []ElementUsage {
+	for i := range usage {
+		if !usage[i].FirstSeen.IsZero() {
+			usage[i].FirstSeen = usage[i].FirstSeen.UTC()
+			usage[i].LastSeen = usage[i].LastSeen.UTC()
+		}
+	}
+	return usage
+}
@@ -0,0 +1,168 @@
+package apideprecation
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// WarningHeader is the gRPC metadata key and the HTTP header name used to send
+// deprecation warnings to clients when WithWarnings is enabled. Values are
+// printable ASCII: whitespace, e.g. line breaks of multi-line descriptions, is
+// collapsed into single spaces, and other bytes, e.g. of non-ASCII text, are
+// percent-encoded, as in grpc-message.
+const WarningHeader = "deprecation-warning"
+
+// WithWarnings sends a human-readable warning for every distinct deprecated
+// method, field, and enum value used by a call back to the client: in the
+// WarningHeader response header (or trailer, if headers have already been
+// sent) for gRPC, and in the WarningHeader response header for connect and HTTP.
+// Notice: This API is EXPERIMENTAL and may be changed or removed in a later release.
+func WithWarnings() Option {
+	return func(c *config) {
+		c.warnings = true
+	}
+}
+
+// warnings collects distinct deprecation warnings of a single call, as valid
+// header values, see headerValue.
+type warnings []string
+
+func (w *warnings) add(warning string) {
+	warning = headerValue(warning)
+	if !slices.Contains(*w, warning) {
+		*w = append(*w, warning)
+	}
+}
+
+// filter returns the warnings of other that are not in w.
+func (w warnings) filter(other warnings) warnings {
+	var res warnings
+	for _, v := range other {
+		if !slices.Contains(w, v) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// pairs returns warnings as metadata key-value pairs.
+func (w warnings) pairs() []string {
+	kv := make([]string, 0, 2*len(w))
+	for _, v := range w {
+		kv = append(kv, WarningHeader, v)
+	}
+	return kv
+}
+
+// addTo adds warnings to HTTP headers, skipping the ones already present,
+// e.g. added for a previous message of the same stream.
+func (w warnings) addTo(h http.Header) {
+	for _, v := range w {
+		if !slices.Contains(h.Values(WarningHeader), v) {
+			h.Add(WarningHeader, v)
+		}
+	}
+}
+
+func methodWarning(md protoreflect.MethodDescriptor) string {
+	return deprecationWarning("method", string(md.FullName()), md)
+}
+
+func fieldWarning(fd protoreflect.FieldDescriptor, fieldPath string) string {
+	desc := deprecatedFieldDescriptor(fd)
+	return deprecationWarning("field", fieldPath+" ("+string(desc.FullName())+")", desc)
+}
+
+// deprecatedFieldDescriptor returns the deprecated descriptor of a reported
+// field: the field itself, or its deprecated message type if the field is
+// referenced by a FieldMask, see WithFieldMasks.
+func deprecatedFieldDescriptor(fd protoreflect.FieldDescriptor) protoreflect.Descriptor {
+	if !isFieldDeprecated(fd) && fd.Message() != nil {
+		return fd.Message()
+	}
+	return fd
+}
+
+func enumValueWarning(evd protoreflect.EnumValueDescriptor, fieldPath string) string {
+	return deprecationWarning("enum value", string(evd.FullName())+" in "+fieldPath, evd)
+}
+
+// headerValue makes a warning a valid gRPC metadata and HTTP header value, as
+// free-form DeprecationDetails may span lines or contain non-ASCII text: runs
+// of whitespace are collapsed into a single space, and bytes outside printable
+// ASCII, as well as '%', are percent-encoded, as in grpc-message.
+func headerValue(s string) string {
+	valid := true
+	for i := range len(s) {
+		if c := s[i]; c < 0x20 || c > 0x7e || c == '%' || c == ' ' && (i == 0 || i == len(s)-1 || s[i-1] == ' ') {
+			valid = false
+			break
+		}
+	}
+	if valid {
+		return s
+	}
+
+	var sb strings.Builder
+	space := false
+	for i := range len(s) {
+		c := s[i]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f' {
+			space = true
+			continue
+		}
+		if space && sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		space = false
+		if c < 0x20 || c > 0x7e || c == '%' {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// deprecationWarning renders a warning, e.g.
+// `method pkg.Service.Method is deprecated and will stop working on 2025-06-01: Use Other instead.`
+// followed by the replacement and the documentation URL, if set, e.g.
+// ` (replacement: pkg.Service.Other, docs: https://example.com/docs)`.
+func deprecationWarning(kind, name string, desc protoreflect.Descriptor) string {
+	details := DeprecationDetails(desc)
+
+	var sb strings.Builder
+	sb.WriteString(kind)
+	sb.WriteByte(' ')
+	sb.WriteString(name)
+	sb.WriteString(" is deprecated")
+	if effectiveAt := details.GetEffectiveAt(); effectiveAt != "" {
+		sb.WriteString(" and will stop working on ")
+		sb.WriteString(effectiveAt)
+	}
+	if description := details.GetDescription(); description != "" {
+		sb.WriteString(": ")
+		sb.WriteString(description)
+	}
+	replacement, docsURL := details.GetReplacement(), details.GetDocsUrl()
+	if replacement != "" || docsURL != "" {
+		sb.WriteString(" (")
+		if replacement != "" {
+			sb.WriteString("replacement: ")
+			sb.WriteString(replacement)
+		}
+		if docsURL != "" {
+			if replacement != "" {
+				sb.WriteString(", ")
+			}
+			sb.WriteString("docs: ")
+			sb.WriteString(docsURL)
+		}
+		sb.WriteByte(')')
+	}
+	return sb.String()
+}